@@ -0,0 +1,305 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+const (
+	// AggregateAttribute is the DeviceObject attribute which lists the
+	// aggregation operators applied to readings of the object, separated
+	// by commas, e.g. "min,max,mean".
+	AggregateAttribute = "aggregate"
+	// AggregateWindowAttribute is the DeviceObject attribute which
+	// specifies the length of the tumbling aggregation window, as a
+	// duration string, e.g. "10s".
+	AggregateWindowAttribute = "aggregateWindow"
+
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+	AggregateMean  = "mean"
+	AggregateLast  = "last"
+	AggregateCount = "count"
+
+	// aggregateFlushInterval is how often windows are checked for expiry
+	// when no readings arrive.
+	aggregateFlushInterval = time.Second
+)
+
+// aggregation is the aggregation configuration of a DeviceObject.
+type aggregation struct {
+	ops    []string
+	window time.Duration
+}
+
+// aggregateWindow accumulates the readings of a single device resource
+// during the current window.
+type aggregateWindow struct {
+	agg   aggregation
+	start time.Time
+	count int
+	// numeric is set while all values in the window are numeric
+	numeric  bool
+	min, max float64
+	sum      float64
+	last     models.Reading
+}
+
+// aggregator replaces the readings of aggregated device resources with
+// readings of the configured aggregates, emitted at the end of each
+// tumbling window. Aggregate readings are named <resource>_<operator>.
+type aggregator struct {
+	mutex sync.Mutex
+	// windows is keyed by device name, then reading name
+	windows map[string]map[string]*aggregateWindow
+}
+
+var ag = newAggregator()
+
+func newAggregator() *aggregator {
+	return &aggregator{windows: make(map[string]map[string]*aggregateWindow)}
+}
+
+// aggregationOf returns the aggregation configuration of the given
+// DeviceObject, if any.
+func aggregationOf(devObj *models.DeviceObject) (aggregation, bool, error) {
+	var agg aggregation
+
+	ops, ok := AttributeString(devObj.Attributes, AggregateAttribute)
+	if !ok || ops == "" {
+		return agg, false, nil
+	}
+
+	for _, op := range strings.Split(ops, ",") {
+		op = strings.TrimSpace(op)
+		switch op {
+		case AggregateMin, AggregateMax, AggregateMean, AggregateLast, AggregateCount:
+			agg.ops = append(agg.ops, op)
+		default:
+			return agg, false, fmt.Errorf("devobject: %s has invalid aggregate operator: %s", devObj.Name, op)
+		}
+	}
+
+	window, _ := AttributeString(devObj.Attributes, AggregateWindowAttribute)
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return agg, false, fmt.Errorf("devobject: %s has invalid %s: %s", devObj.Name, AggregateWindowAttribute, window)
+	}
+	agg.window = d
+
+	return agg, true, nil
+}
+
+// aggregateName returns the name of the reading of the given aggregate.
+func aggregateName(name string, op string) string {
+	return name + "_" + op
+}
+
+// aggregate adds the readings of aggregated device resources in the given
+// event to their windows. It returns an event containing the remaining
+// readings, and the aggregate readings of any windows which have ended.
+func (a *aggregator) aggregate(event *models.Event) (*models.Event, []models.Reading) {
+	devObjs := pc.getReadingObjects(event.Device)
+	now := clock.Now()
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	out := *event
+	out.Readings = make([]models.Reading, 0, len(event.Readings))
+	var aggs []models.Reading
+
+	for _, r := range event.Readings {
+		devObj, ok := devObjs[r.Name]
+		if !ok {
+			out.Readings = append(out.Readings, r)
+			continue
+		}
+
+		agg, ok, err := aggregationOf(&devObj)
+		if err != nil {
+			svc.lc.Error(err.Error())
+		}
+
+		if !ok {
+			out.Readings = append(out.Readings, r)
+			continue
+		}
+
+		aggs = append(aggs, a.add(event.Device, r, agg, now)...)
+	}
+
+	return &out, aggs
+}
+
+// add adds a reading to its window, returning the aggregate readings of
+// the previous window if it has ended.
+func (a *aggregator) add(devName string, r models.Reading, agg aggregation, now time.Time) []models.Reading {
+	devWindows, ok := a.windows[devName]
+	if !ok {
+		devWindows = make(map[string]*aggregateWindow)
+		a.windows[devName] = devWindows
+	}
+
+	var readings []models.Reading
+
+	w, ok := devWindows[r.Name]
+	if ok && now.Sub(w.start) >= w.agg.window {
+		readings = w.readings()
+		ok = false
+	}
+
+	if !ok {
+		w = &aggregateWindow{agg: agg, start: now, numeric: true}
+		devWindows[r.Name] = w
+	}
+
+	w.add(r)
+	return readings
+}
+
+// flush removes windows which have ended, and returns events containing
+// their aggregates, so that aggregates are emitted even if no further
+// readings arrive.
+func (a *aggregator) flush(now time.Time) []*models.Event {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	var events []*models.Event
+	for devName, devWindows := range a.windows {
+		var readings []models.Reading
+		for name, w := range devWindows {
+			if now.Sub(w.start) >= w.agg.window {
+				readings = append(readings, w.readings()...)
+				delete(devWindows, name)
+			}
+		}
+
+		if len(readings) > 0 {
+			event := &models.Event{Device: devName, Readings: readings}
+			event.Origin = timestamp(now)
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// removeDevice discards the windows of the named device.
+func (a *aggregator) removeDevice(devName string) {
+	a.mutex.Lock()
+	delete(a.windows, devName)
+	a.mutex.Unlock()
+}
+
+func (w *aggregateWindow) add(r models.Reading) {
+	w.count++
+	w.last = r
+
+	if !w.numeric {
+		return
+	}
+
+	v, err := strconv.ParseFloat(r.Value, 64)
+	if err != nil {
+		w.numeric = false
+		return
+	}
+
+	if w.count == 1 || v < w.min {
+		w.min = v
+	}
+
+	if w.count == 1 || v > w.max {
+		w.max = v
+	}
+
+	w.sum += v
+}
+
+// readings returns the aggregate readings of the window. Numeric
+// aggregates are omitted if any value in the window isn't numeric.
+func (w *aggregateWindow) readings() []models.Reading {
+	var readings []models.Reading
+
+	for _, op := range w.agg.ops {
+		var value string
+		switch op {
+		case AggregateMin:
+			value = strconv.FormatFloat(w.min, 'g', -1, 64)
+		case AggregateMax:
+			value = strconv.FormatFloat(w.max, 'g', -1, 64)
+		case AggregateMean:
+			value = strconv.FormatFloat(w.sum/float64(w.count), 'g', -1, 64)
+		case AggregateLast:
+			value = w.last.Value
+		case AggregateCount:
+			value = strconv.Itoa(w.count)
+		}
+
+		if !w.numeric && op != AggregateLast && op != AggregateCount {
+			svc.lc.Warn(fmt.Sprintf("dev: %s reading: %s has non-numeric values; %s omitted", w.last.Device, w.last.Name, op))
+			continue
+		}
+
+		readings = append(readings, models.Reading{
+			Device: w.last.Device,
+			Name:   aggregateName(w.last.Name, op),
+			Value:  value,
+			Origin: w.last.Origin,
+		})
+	}
+
+	return readings
+}
+
+// exportEvent passes an event produced by the DS through the event
+// pipeline shared by commands and async readings. The event is recorded
+// locally, and readings of aggregated device resources are replaced by
+// their aggregates. It returns the event to be pushed to Core Data, or
+// nil if no readings remain.
+func exportEvent(event *models.Event) *models.Event {
+	if len(event.Readings) == 0 {
+		return nil
+	}
+
+	recordEvent(event)
+
+	out, aggs := ag.aggregate(event)
+	if len(aggs) > 0 {
+		aggEvent := *event
+		aggEvent.Readings = aggs
+		recordEvent(&aggEvent)
+
+		out.Readings = append(out.Readings, aggs...)
+	}
+
+	if len(out.Readings) == 0 {
+		return nil
+	}
+
+	return out
+}
+
+// flushAggregates periodically emits the aggregates of ended windows.
+func flushAggregates(interval time.Duration) {
+	for !svc.stopped {
+		time.Sleep(interval)
+
+		for _, event := range ag.flush(clock.Now()) {
+			recordEvent(event)
+			sendEvent(event)
+		}
+	}
+}
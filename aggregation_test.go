@@ -0,0 +1,131 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"testing"
+	"time"
+
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+func TestAggregationOf(t *testing.T) {
+	var tests = []struct {
+		name  string
+		attrs interface{}
+		ok    bool
+		err   bool
+	}{
+		{"none", nil, false, false},
+		{"yaml", map[interface{}]interface{}{"aggregate": "min, max", "aggregateWindow": "10s"}, true, false},
+		{"json", map[string]interface{}{"aggregate": "mean", "aggregateWindow": "1m"}, true, false},
+		{"bad operator", map[string]interface{}{"aggregate": "median", "aggregateWindow": "1m"}, false, true},
+		{"no window", map[string]interface{}{"aggregate": "mean"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devObj := models.DeviceObject{Name: "Temperature", Attributes: tt.attrs}
+			_, ok, err := aggregationOf(&devObj)
+			if ok != tt.ok || (err != nil) != tt.err {
+				t.Errorf("aggregationOf: expected ok: %v err: %v, got ok: %v err: %v", tt.ok, tt.err, ok, err)
+			}
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	svc = &Service{c: &Config{}, lc: logger.NewClient("aggregation_test", false, "")}
+	pc = &profileCache{objects: map[string]map[string]models.DeviceObject{
+		"dev": {
+			"Vibration": {Name: "Vibration", Attributes: map[string]interface{}{
+				"aggregate": "min,max,mean,last,count", "aggregateWindow": "1h"}},
+			"Status": {Name: "Status"},
+		},
+	}}
+
+	a := newAggregator()
+	for _, v := range []string{"3", "1", "5"} {
+		out, aggs := a.aggregate(&models.Event{Device: "dev", Readings: []models.Reading{
+			{Device: "dev", Name: "Vibration", Value: v},
+			{Device: "dev", Name: "Status", Value: "OK"},
+		}})
+
+		// only the unaggregated reading passes through
+		if len(out.Readings) != 1 || out.Readings[0].Name != "Status" || len(aggs) != 0 {
+			t.Fatalf("aggregate: wrong readings: %v aggregates: %v", out.Readings, aggs)
+		}
+	}
+
+	if events := a.flush(time.Now()); len(events) != 0 {
+		t.Errorf("flush: window flushed early: %v", events)
+	}
+
+	events := a.flush(time.Now().Add(time.Hour))
+	if len(events) != 1 {
+		t.Fatalf("flush: expected 1 event, got: %v", events)
+	}
+
+	expected := map[string]string{
+		"Vibration_min":   "1",
+		"Vibration_max":   "5",
+		"Vibration_mean":  "3",
+		"Vibration_last":  "5",
+		"Vibration_count": "3",
+	}
+
+	if len(events[0].Readings) != len(expected) {
+		t.Fatalf("flush: wrong aggregates: %v", events[0].Readings)
+	}
+
+	for _, r := range events[0].Readings {
+		if expected[r.Name] != r.Value {
+			t.Errorf("flush: aggregate: %s expected: %s, got: %s", r.Name, expected[r.Name], r.Value)
+		}
+	}
+
+	if events = a.flush(time.Now().Add(2 * time.Hour)); len(events) != 0 {
+		t.Errorf("flush: window flushed twice: %v", events)
+	}
+}
+
+func TestAggregateParameterReading(t *testing.T) {
+	svc = &Service{c: &Config{}, lc: logger.NewClient("aggregation_test", false, "")}
+
+	// readings of the command are named after the parameter, which has a
+	// value descriptor, rather than the device resource
+	pc = &profileCache{
+		objects: map[string]map[string]models.DeviceObject{
+			"dev": {
+				"accel": {Name: "accel", Attributes: map[string]interface{}{
+					"aggregate": "max", "aggregateWindow": "1h"}},
+			},
+		},
+		commands: map[string]map[string]map[string][]models.ResourceOperation{
+			"dev": {"vibration": {"get": {{Operation: "get", Object: "accel", Parameter: "Vibration"}}}},
+		},
+		descriptors: []models.ValueDescriptor{{Name: "Vibration"}},
+	}
+
+	a := newAggregator()
+	for _, v := range []string{"3", "7"} {
+		out, aggs := a.aggregate(&models.Event{Device: "dev", Readings: []models.Reading{
+			{Device: "dev", Name: "Vibration", Value: v},
+		}})
+
+		if len(out.Readings) != 0 || len(aggs) != 0 {
+			t.Fatalf("aggregate: parameter reading not aggregated: %v aggregates: %v", out.Readings, aggs)
+		}
+	}
+
+	events := a.flush(time.Now().Add(time.Hour))
+	if len(events) != 1 || len(events[0].Readings) != 1 ||
+		events[0].Readings[0].Name != "Vibration_max" || events[0].Readings[0].Value != "7" {
+		t.Errorf("flush: expected Vibration_max: 7, got: %v", events)
+	}
+}
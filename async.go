@@ -22,18 +22,44 @@ func processAsyncResults() {
 
 		// get the device resource associated with the rsp.RO
 		do := pc.getDeviceObjectByName(cr.DeviceName, cr.RO)
+		if do == nil {
+			svc.lc.Error(fmt.Sprintf("dropping async reading; no device resource: %s for dev: %s", cr.RO.Object, cr.DeviceName))
+			continue
+		}
 
 		_ = cr.TransformResult(do.Properties.Value)
 
-		reading := cr.Reading(cr.DeviceName, do.Name)
+		quality, err := validateResult(cr, cr.DeviceName, do)
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("dropping async reading; %v", err))
+			continue
+		}
+
+		err = cr.convertUnits(do)
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("dropping async reading; %v", err))
+			continue
+		}
+
+		reading := newReading(cr, cr.DeviceName, do)
+		reading.Value = mapValue(cr.RO, reading.Value)
+
+		err = checkValueLen(reading, cr.Type)
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("dropping async reading; %v", err))
+			continue
+		}
+
 		readings = append(readings, *reading)
+		if quality != "" {
+			readings = append(readings, qualityReading(reading, quality))
+		}
 
 		// push to Core Data
 		event := &models.Event{Device: cr.DeviceName, Readings: readings}
-		_, err := svc.ec.Add(event)
-		if err != nil {
-			msg := fmt.Sprintf("internal error; failed to push event for dev: %s to CoreData: %s", cr.DeviceName, err)
-			svc.lc.Error(msg)
+		event.Origin = now()
+		if e := exportEvent(event); e != nil {
+			sendEvent(e)
 		}
 	}
 }
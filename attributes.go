@@ -0,0 +1,369 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// attrTag is the struct tag which names the attribute decoded into a
+	// field, optionally followed by ",required". A name of "-" skips the
+	// field. Fields are named by their name with a lower-case first letter
+	// by default.
+	attrTag = "attr"
+	// defaultTag is the struct tag which holds the value of a field whose
+	// attribute isn't set.
+	defaultTag = "default"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var attributeSchemaMutex sync.RWMutex
+
+// attributeSchema is the struct type registered by the ProtocolDriver.
+var attributeSchema reflect.Type
+
+// RegisterAttributeSchema registers the struct (or pointer to struct)
+// into which the ProtocolDriver decodes DeviceObject attributes using
+// DecodeAttributes. Once registered, the attributes of each DeviceObject
+// in a deviceprofile are validated against the schema when the profile is
+// loaded, except those of bit fields, which are read by the SDK. The
+// schema must be registered before the Service is started.
+func RegisterAttributeSchema(schema interface{}) error {
+	t := reflect.TypeOf(schema)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("attribute schema: %T isn't a struct", schema)
+	}
+
+	fields, err := attributeFields(t)
+	if err != nil {
+		return err
+	}
+
+	// check that the defaults decode into their fields
+	v := reflect.New(t).Elem()
+	for _, f := range fields {
+		if f.def != "" {
+			if err := setAttribute(v.Field(f.index), f.def); err != nil {
+				return fmt.Errorf("attribute schema: %s default: %v", f.name, err)
+			}
+		}
+	}
+
+	attributeSchemaMutex.Lock()
+	attributeSchema = t
+	attributeSchemaMutex.Unlock()
+
+	return nil
+}
+
+// DecodeAttributes decodes DeviceObject attributes into the struct
+// pointed to by v. Each exported field is set from the attribute named by
+// its attr tag, or from its default tag if the attribute isn't set, and
+// an error is returned if a required attribute isn't set. Attribute
+// values are converted to the field type, so "502" may be decoded into an
+// int, and "true" into a bool. Fields may be strings, bools, integers
+// (in decimal, or hex with a 0x prefix), floats, time.Durations, pointers
+// to these (which are left nil if the attribute isn't set), or slices of
+// these, which are decoded from a list or a comma separated string.
+// Attributes without a field are ignored.
+func DecodeAttributes(attrs interface{}, v interface{}) error {
+	pv := reflect.ValueOf(v)
+	if pv.Kind() != reflect.Ptr || pv.IsNil() || pv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("attributes: can't decode into %T; expected pointer to struct", v)
+	}
+
+	sv := pv.Elem()
+	fields, err := attributeFields(sv.Type())
+	if err != nil {
+		return err
+	}
+
+	var msgs []string
+	for _, f := range fields {
+		value, ok := attributeValue(attrs, f.name)
+		if !ok {
+			if f.required {
+				msgs = append(msgs, fmt.Sprintf("missing required attribute: %s", f.name))
+				continue
+			}
+
+			if f.def == "" {
+				continue
+			}
+			value = f.def
+		}
+
+		if err := setAttribute(sv.Field(f.index), value); err != nil {
+			msgs = append(msgs, fmt.Sprintf("attribute: %s %v", f.name, err))
+		}
+	}
+
+	if len(msgs) > 0 {
+		return fmt.Errorf("%s", strings.Join(msgs, "; "))
+	}
+
+	return nil
+}
+
+// Attributes decodes the attributes of the request's DeviceObject into
+// the struct pointed to by v, as per DecodeAttributes.
+func (req *CommandRequest) Attributes(v interface{}) error {
+	return DecodeAttributes(req.DeviceObject.Attributes, v)
+}
+
+// AttributeString returns the named attribute from DeviceObject attributes,
+// which are decoded as a map[interface{}]interface{} from YAML profiles, and
+// a map[string]interface{} from JSON profiles, formatted as a string.
+func AttributeString(attrs interface{}, name string) (string, bool) {
+	v, ok := attributeValue(attrs, name)
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf("%v", v), true
+}
+
+// attributeValue returns the named attribute from DeviceObject attributes,
+// as decoded from the profile.
+func attributeValue(attrs interface{}, name string) (interface{}, bool) {
+	var v interface{}
+	var ok bool
+
+	switch attrs := attrs.(type) {
+	case map[string]interface{}:
+		v, ok = attrs[name]
+	case map[interface{}]interface{}:
+		v, ok = attrs[name]
+	case map[string]string:
+		v, ok = attrs[name]
+	}
+
+	return v, ok && v != nil
+}
+
+// validateAttributes decodes the attributes of a DeviceObject into the
+// registered attribute schema, if any, and returns any error.
+func validateAttributes(attrs interface{}) error {
+	attributeSchemaMutex.RLock()
+	t := attributeSchema
+	attributeSchemaMutex.RUnlock()
+
+	if t == nil {
+		return nil
+	}
+
+	return DecodeAttributes(attrs, reflect.New(t).Interface())
+}
+
+// attributeField describes the attribute decoded into a struct field.
+type attributeField struct {
+	index    int
+	name     string
+	def      string
+	required bool
+}
+
+// attributeFields returns the attribute fields of a struct type, or an
+// error if any field has an unsupported type.
+func attributeFields(t reflect.Type) ([]attributeField, error) {
+	var fields []attributeField
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		f := attributeField{index: i, def: sf.Tag.Get(defaultTag)}
+
+		tag := strings.Split(sf.Tag.Get(attrTag), ",")
+		f.name = tag[0]
+		if f.name == "-" {
+			continue
+		}
+
+		if f.name == "" {
+			r, n := utf8.DecodeRuneInString(sf.Name)
+			f.name = string(unicode.ToLower(r)) + sf.Name[n:]
+		}
+
+		for _, opt := range tag[1:] {
+			if opt != "required" {
+				return nil, fmt.Errorf("attribute schema: field %s has invalid option: %s", sf.Name, opt)
+			}
+			f.required = true
+		}
+
+		if !attributeTypeSupported(sf.Type, true) {
+			return nil, fmt.Errorf("attribute schema: field %s has unsupported type: %v", sf.Name, sf.Type)
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+// attributeTypeSupported returns whether an attribute can be decoded into
+// a field of the given type. Pointers and slices may not be nested.
+func attributeTypeSupported(t reflect.Type, outer bool) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return outer && attributeTypeSupported(t.Elem(), false)
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+// setAttribute sets the field to the given attribute value.
+func setAttribute(field reflect.Value, value interface{}) error {
+	switch field.Kind() {
+	case reflect.Ptr:
+		p := reflect.New(field.Type().Elem())
+		if err := setAttribute(p.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(p)
+		return nil
+
+	case reflect.Slice:
+		var values []interface{}
+		switch value := value.(type) {
+		case []interface{}:
+			values = value
+		case string:
+			for _, s := range strings.Split(value, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					values = append(values, s)
+				}
+			}
+		default:
+			values = []interface{}{value}
+		}
+
+		s := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setAttribute(s.Index(i), v); err != nil {
+				return err
+			}
+		}
+		field.Set(s)
+		return nil
+	}
+
+	text := attributeText(value)
+	bits := field.Type().Bits
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("value: %s isn't a bool", text)
+		}
+		field.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Type() == durationType {
+			d, err := time.ParseDuration(text)
+			if err != nil {
+				return fmt.Errorf("value: %s isn't a duration", text)
+			}
+			field.SetInt(int64(d))
+			break
+		}
+
+		i, err := strconv.ParseInt(integerText(text), integerBase(text), bits())
+		if err != nil {
+			return fmt.Errorf("value: %s isn't a %v", text, field.Type())
+		}
+		field.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(integerText(text), integerBase(text), bits())
+		if err != nil {
+			return fmt.Errorf("value: %s isn't a %v", text, field.Type())
+		}
+		field.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, bits())
+		if err != nil {
+			return fmt.Errorf("value: %s isn't a %v", text, field.Type())
+		}
+		field.SetFloat(f)
+
+	default:
+		return fmt.Errorf("has unsupported type: %v", field.Type())
+	}
+
+	return nil
+}
+
+// integerBase returns 16 if the given integer attribute has a 0x prefix,
+// and 10 otherwise, so leading zeros don't select octal.
+func integerBase(text string) int {
+	if hexPrefix(text) >= 0 {
+		return 16
+	}
+	return 10
+}
+
+// integerText returns the given integer attribute, without any 0x prefix.
+func integerText(text string) string {
+	if i := hexPrefix(text); i >= 0 {
+		return text[:i] + text[i+2:]
+	}
+	return text
+}
+
+// hexPrefix returns the index of the 0x prefix of the given integer
+// attribute, after any sign, or -1 if it hasn't one.
+func hexPrefix(text string) int {
+	i := 0
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		i = 1
+	}
+	if strings.HasPrefix(text[i:], "0x") || strings.HasPrefix(text[i:], "0X") {
+		return i
+	}
+	return -1
+}
+
+// attributeText returns an attribute value as a string. Floats, which
+// JSON profiles decode all numbers as, are formatted without an exponent.
+func attributeText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
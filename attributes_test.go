@@ -0,0 +1,167 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+type registerAttributes struct {
+	Register uint16 `attr:"register,required"`
+	Unit     uint8  `default:"1"`
+	Scale    float64
+	Poll     time.Duration `attr:"pollInterval" default:"1s"`
+	Enabled  *bool
+	Tags     []string
+	Masks    []uint32
+	Ignored  string `attr:"-"`
+	internal string
+}
+
+func TestDecodeAttributes(t *testing.T) {
+	enabled := true
+
+	var tests = []struct {
+		name     string
+		attrs    interface{}
+		expected registerAttributes
+	}{
+		{"YAML",
+			map[interface{}]interface{}{"register": 40001, "unit": "3", "scale": 0.5, "enabled": "true", "tags": []interface{}{"a", "b"}},
+			registerAttributes{Register: 40001, Unit: 3, Scale: 0.5, Poll: time.Second, Enabled: &enabled, Tags: []string{"a", "b"}}},
+		{"JSON",
+			map[string]interface{}{"register": float64(502), "pollInterval": "250ms", "masks": "0xff00, 0x00ff", "Ignored": "x"},
+			registerAttributes{Register: 502, Unit: 1, Poll: 250 * time.Millisecond, Masks: []uint32{0xff00, 0x00ff}}},
+		{"Strings",
+			map[string]string{"register": "0x10", "scale": "1e3", "tags": "single"},
+			registerAttributes{Register: 16, Unit: 1, Scale: 1000, Poll: time.Second, Tags: []string{"single"}}},
+		{"Leading zeros",
+			map[string]string{"register": "0100", "unit": "08", "masks": "0X0f, 010"},
+			registerAttributes{Register: 100, Unit: 8, Poll: time.Second, Masks: []uint32{15, 10}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attrs registerAttributes
+			err := DecodeAttributes(tt.attrs, &attrs)
+			if err != nil {
+				t.Fatalf("DecodeAttributes: unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(attrs, tt.expected) {
+				t.Errorf("DecodeAttributes: expected: %+v, got: %+v", tt.expected, attrs)
+			}
+		})
+	}
+}
+
+func TestDecodeAttributesErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		attrs interface{}
+		msgs  []string
+	}{
+		{"Missing required", map[string]interface{}{"unit": 1}, []string{"missing required attribute: register"}},
+		{"Overflow", map[string]interface{}{"register": 70000}, []string{"attribute: register value: 70000"}},
+		{"Several", map[string]interface{}{"register": 1, "scale": "high", "pollInterval": 5, "masks": []interface{}{1, -1}},
+			[]string{"attribute: scale", "attribute: pollInterval", "attribute: masks"}},
+		{"Nil attributes", nil, []string{"missing required attribute: register"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attrs registerAttributes
+			err := DecodeAttributes(tt.attrs, &attrs)
+			if err == nil {
+				t.Fatalf("DecodeAttributes: expected error")
+			}
+
+			for _, msg := range tt.msgs {
+				if !strings.Contains(err.Error(), msg) {
+					t.Errorf("DecodeAttributes: expected error containing: %s, got: %v", msg, err)
+				}
+			}
+		})
+	}
+
+	var attrs registerAttributes
+	if err := DecodeAttributes(nil, attrs); err == nil {
+		t.Errorf("DecodeAttributes: expected error decoding into non-pointer")
+	}
+}
+
+func TestRegisterAttributeSchema(t *testing.T) {
+	defer func() { attributeSchema = nil }()
+
+	var tests = []struct {
+		name   string
+		schema interface{}
+		valid  bool
+	}{
+		{"Struct", registerAttributes{}, true},
+		{"Pointer", &registerAttributes{}, true},
+		{"Not a struct", "register", false},
+		{"Nil", nil, false},
+		{"Unsupported type", struct{ M map[string]string }{}, false},
+		{"Nested slice", struct{ S [][]int }{}, false},
+		{"Bad default", struct {
+			N int `default:"many"`
+		}{}, false},
+		{"Bad option", struct {
+			N int `attr:"n,optional"`
+		}{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterAttributeSchema(tt.schema)
+			if tt.valid && err != nil {
+				t.Errorf("RegisterAttributeSchema: unexpected error: %v", err)
+			} else if !tt.valid && err == nil {
+				t.Errorf("RegisterAttributeSchema: expected error")
+			}
+		})
+	}
+}
+
+func TestValidateProfileAttributes(t *testing.T) {
+	err := RegisterAttributeSchema(registerAttributes{})
+	if err != nil {
+		t.Fatalf("RegisterAttributeSchema: unexpected error: %v", err)
+	}
+	defer func() { attributeSchema = nil }()
+
+	resource := func(name string, pvType string, attrs map[string]interface{}) models.DeviceObject {
+		do := models.DeviceObject{Name: name, Attributes: attrs}
+		do.Properties.Value = models.PropertyValue{Type: pvType, ReadWrite: "RW"}
+		return do
+	}
+
+	profile := models.DeviceProfile{
+		Name: "registers",
+		DeviceResources: []models.DeviceObject{
+			resource("status", "Uint16", map[string]interface{}{"register": 1}),
+			resource("alarm", "Bool", map[string]interface{}{BitSourceAttribute: "status", BitOffsetAttribute: 0}),
+			resource("level", "Uint16", map[string]interface{}{"unit": "2"}),
+		},
+	}
+
+	err = ValidateProfile(profile)
+	if err == nil {
+		t.Fatalf("ValidateProfile: expected error")
+	}
+
+	errs := err.(ProfileErrors)
+	if len(errs) != 1 || !strings.Contains(errs[0].Msg, "device resource level: missing required attribute: register") {
+		t.Errorf("ValidateProfile: expected missing register error for level only, got: %v", err)
+	}
+}
@@ -0,0 +1,441 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+const (
+	// BitSourceAttribute is the DeviceObject attribute which makes the
+	// object a bit field of another (source) device object, which must be
+	// an unsigned integer, e.g. a Uint16 register which packs several
+	// flags. The bit field is read from the source by the SDK, and a PUT
+	// of the bit field is a read-modify-write of the source. A bit field
+	// of length 1 may have type Bool; otherwise its type must be an
+	// unsigned integer. Enumerated values can be named using the
+	// ResourceOperation's Mappings.
+	BitSourceAttribute = "bitSource"
+	// BitOffsetAttribute is the bit field's offset from the least
+	// significant bit of the source.
+	BitOffsetAttribute = "bitOffset"
+	// BitLengthAttribute is the bit field's number of bits; 1 if unset.
+	BitLengthAttribute = "bitLength"
+)
+
+// bitField is the bit field configuration of a DeviceObject.
+type bitField struct {
+	source string
+	offset uint
+	length uint
+}
+
+// fieldOp is a bit field ResourceOperation of a command.
+type fieldOp struct {
+	ro     models.ResourceOperation
+	devObj models.DeviceObject
+	field  bitField
+}
+
+// bitFieldOps holds the bit field operations of a command.
+type bitFieldOps struct {
+	fields []fieldOp
+	// direct lists the device objects requested directly by the command,
+	// whose results are kept even if they're bit field sources
+	direct map[string]bool
+}
+
+// bitFieldOf returns the bit field configuration of the given
+// DeviceObject, if any.
+func bitFieldOf(devObj *models.DeviceObject) (bitField, bool, error) {
+	source, ok := AttributeString(devObj.Attributes, BitSourceAttribute)
+	if !ok || source == "" {
+		return bitField{}, false, nil
+	}
+
+	f := bitField{source: source, length: 1}
+
+	offset, _ := AttributeString(devObj.Attributes, BitOffsetAttribute)
+	o, err := strconv.ParseUint(offset, 10, 8)
+	if err != nil {
+		return f, false, fmt.Errorf("devobject: %s has invalid %s: %s", devObj.Name, BitOffsetAttribute, offset)
+	}
+	f.offset = uint(o)
+
+	if length, ok := AttributeString(devObj.Attributes, BitLengthAttribute); ok {
+		l, err := strconv.ParseUint(length, 10, 8)
+		if err != nil || l == 0 {
+			return f, false, fmt.Errorf("devobject: %s has invalid %s: %s", devObj.Name, BitLengthAttribute, length)
+		}
+		f.length = uint(l)
+	}
+
+	if f.offset+f.length > 64 {
+		return f, false, fmt.Errorf("devobject: %s bit field exceeds 64 bits", devObj.Name)
+	}
+
+	t := strings.ToLower(devObj.Properties.Value.Type)
+	if (t == "bool" || t == "boolean") && f.length != 1 {
+		return f, false, fmt.Errorf("devobject: %s bit field of type: %s must have %s: 1", devObj.Name, devObj.Properties.Value.Type, BitLengthAttribute)
+	} else if rt, ok := propertyTypes[t]; ok && rt >= Uint8 && rt <= Uint64 {
+		if f.length > uint(resultBits(rt)) {
+			return f, false, fmt.Errorf("devobject: %s bit field too long for type: %s", devObj.Name, devObj.Properties.Value.Type)
+		}
+	} else if t != "bool" && t != "boolean" {
+		return f, false, fmt.Errorf("devobject: %s bit field has invalid type: %s", devObj.Name, devObj.Properties.Value.Type)
+	}
+
+	return f, true, nil
+}
+
+// validateBitFields returns a description of each problem found with the
+// bit fields of the given device objects, keyed by device object name.
+func validateBitFields(devObjs []models.DeviceObject) map[string]string {
+	problems := make(map[string]string)
+
+	byName := make(map[string]*models.DeviceObject, len(devObjs))
+	for i := range devObjs {
+		byName[devObjs[i].Name] = &devObjs[i]
+	}
+
+	for i := range devObjs {
+		do := &devObjs[i]
+
+		f, ok, err := bitFieldOf(do)
+		if err != nil {
+			problems[do.Name] = err.Error()
+			continue
+		} else if !ok {
+			continue
+		}
+
+		src, ok := byName[f.source]
+		if !ok {
+			problems[do.Name] = fmt.Sprintf("devobject: %s has unknown %s: %s", do.Name, BitSourceAttribute, f.source)
+			continue
+		}
+
+		if _, nested, _ := bitFieldOf(src); nested {
+			problems[do.Name] = fmt.Sprintf("devobject: %s %s: %s is itself a bit field", do.Name, BitSourceAttribute, f.source)
+			continue
+		}
+
+		t, ok := propertyTypes[strings.ToLower(src.Properties.Value.Type)]
+		if !ok || t < Uint8 || t > Uint64 {
+			problems[do.Name] = fmt.Sprintf("devobject: %s %s: %s must be an unsigned integer", do.Name, BitSourceAttribute, f.source)
+		} else if f.offset+f.length > uint(resultBits(t)) {
+			problems[do.Name] = fmt.Sprintf("devobject: %s bit field exceeds %s: %s", do.Name, BitSourceAttribute, f.source)
+		}
+	}
+
+	return problems
+}
+
+// mask returns the mask of the bit field's bits, before shifting by its
+// offset.
+func (f bitField) mask() uint64 {
+	if f.length >= 64 {
+		return ^uint64(0)
+	}
+
+	return 1<<f.length - 1
+}
+
+// bitFieldRequests removes the requests for bit field device objects from
+// the given requests, and returns them separately. For a GET, a request
+// for the source of each bit field is added, unless already present.
+func bitFieldRequests(reqs []CommandRequest, devObjs map[string]models.DeviceObject, get bool) ([]CommandRequest, bitFieldOps, error) {
+	bfs := bitFieldOps{direct: make(map[string]bool)}
+	out := make([]CommandRequest, 0, len(reqs))
+
+	for _, req := range reqs {
+		f, ok, err := bitFieldOf(&req.DeviceObject)
+		if err != nil {
+			return nil, bfs, err
+		}
+
+		if !ok {
+			out = append(out, req)
+			bfs.direct[req.RO.Object] = true
+			continue
+		}
+
+		bfs.fields = append(bfs.fields, fieldOp{ro: req.RO, devObj: req.DeviceObject, field: f})
+	}
+
+	if !get {
+		return out, bfs, nil
+	}
+
+	have := make(map[string]bool)
+	for _, fo := range bfs.fields {
+		if bfs.direct[fo.field.source] || have[fo.field.source] {
+			continue
+		}
+
+		src, ok := devObjs[fo.field.source]
+		if !ok {
+			return nil, bfs, fmt.Errorf("no devobject: %s for bit field: %s", fo.field.source, fo.devObj.Name)
+		}
+
+		out = append(out, CommandRequest{RO: secondaryOperation(src.Name), DeviceObject: src})
+		have[src.Name] = true
+	}
+
+	return out, bfs, nil
+}
+
+// extract adds a result for each bit field to the given results, extracted
+// from the result of its source. The results of sources which were only
+// read for their bit fields are removed.
+func (bfs bitFieldOps) extract(results []CommandResult) ([]CommandResult, error) {
+	if len(bfs.fields) == 0 {
+		return results, nil
+	}
+
+	sources := make(map[string]*CommandResult)
+	for i := range results {
+		if results[i].RO != nil {
+			sources[results[i].RO.Object] = &results[i]
+		}
+	}
+
+	var extracted []CommandResult
+	for i := range bfs.fields {
+		fo := &bfs.fields[i]
+
+		src, ok := sources[fo.field.source]
+		if !ok {
+			return nil, fmt.Errorf("no result for bit field source: %s", fo.field.source)
+		}
+
+		u, err := unsignedValue(src)
+		if err != nil {
+			return nil, fmt.Errorf("bit field source: %s; %v", fo.field.source, err)
+		}
+
+		v := u >> fo.field.offset & fo.field.mask()
+
+		ro := fo.ro
+		cr, err := bitFieldResult(&ro, src, fo.devObj, v)
+		if err != nil {
+			return nil, err
+		}
+
+		extracted = append(extracted, *cr)
+	}
+
+	out := make([]CommandResult, 0, len(results)+len(extracted))
+	for _, cr := range results {
+		if cr.RO != nil && !bfs.direct[cr.RO.Object] && bfs.isSource(cr.RO.Object) {
+			continue
+		}
+		out = append(out, cr)
+	}
+
+	return append(out, extracted...), nil
+}
+
+// isSource returns whether the named device object is the source of any
+// of the bit fields.
+func (bfs bitFieldOps) isSource(name string) bool {
+	for _, fo := range bfs.fields {
+		if fo.field.source == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unsignedValue returns the value of an unsigned integer result.
+func unsignedValue(cr *CommandResult) (uint64, error) {
+	if cr.Type < Uint8 || cr.Type > Uint64 {
+		return 0, fmt.Errorf("%v result is not an unsigned integer", cr.Type)
+	}
+
+	if len(cr.NumericResult) != resultBits(cr.Type)/8 {
+		return 0, fmt.Errorf("invalid %v result", cr.Type)
+	}
+
+	return getUint(cr.NumericResult), nil
+}
+
+// bitFieldResult returns the result of a bit field with the given value,
+// whose type is that of the bit field's DeviceObject. The origin of the
+// result is that of its source.
+func bitFieldResult(ro *models.ResourceOperation, src *CommandResult, devObj models.DeviceObject, v uint64) (*CommandResult, error) {
+	t := strings.ToLower(devObj.Properties.Value.Type)
+	if t == "bool" || t == "boolean" {
+		cr := NewBoolResult(ro, nil, src.Origin, v != 0)
+		cr.DeviceId, cr.DeviceName = src.DeviceId, src.DeviceName
+		return cr, nil
+	}
+
+	var value interface{}
+	switch propertyTypes[t] {
+	case Uint8:
+		value = uint8(v)
+	case Uint16:
+		value = uint16(v)
+	case Uint32:
+		value = uint32(v)
+	case Uint64:
+		value = v
+	default:
+		return nil, fmt.Errorf("devobject: %s bit field has invalid type: %s", devObj.Name, devObj.Properties.Value.Type)
+	}
+
+	cr, err := NewCommandResult(ro, nil, src.Origin, value)
+	if err != nil {
+		return nil, err
+	}
+
+	cr.DeviceId, cr.DeviceName = src.DeviceId, src.DeviceName
+	return cr, nil
+}
+
+// write sets the bit fields of a PUT command, whose values are given by
+// the JSON encoded parameters, keyed by ResourceOperation parameter name.
+// The sources of the bit fields are read, the targeted bits modified, and
+// the sources written, leaving their other bits unchanged. Bool bit fields
+// accept true or false, and others an unsigned integer which fits the bit
+// field.
+func (bfs bitFieldOps) write(ctx context.Context, d *models.Device, devObjs map[string]models.DeviceObject, params string) error {
+	if len(bfs.fields) == 0 {
+		return nil
+	}
+
+	var values map[string]interface{}
+	dec := json.NewDecoder(bytes.NewBufferString(params))
+	dec.UseNumber()
+
+	err := dec.Decode(&values)
+	if err != nil {
+		return NewBadParameterError(fmt.Sprintf("invalid parameters; expected JSON object: %v", err))
+	}
+
+	// the sources are read and written while other read-modify-writes
+	// are excluded, so concurrent writes of bit fields aren't lost
+	unlock := ot.lockWrites(d.Name)
+	defer unlock()
+
+	var reqs []CommandRequest
+	have := make(map[string]bool)
+	for _, fo := range bfs.fields {
+		if have[fo.field.source] {
+			continue
+		}
+
+		src, ok := devObjs[fo.field.source]
+		if !ok {
+			return fmt.Errorf("no devobject: %s for bit field: %s", fo.field.source, fo.devObj.Name)
+		}
+
+		reqs = append(reqs, CommandRequest{RO: secondaryOperation(src.Name), DeviceObject: src})
+		have[src.Name] = true
+	}
+
+	results, err := handleCommands(ctx, *d, reqs, "")
+	if err != nil {
+		return err
+	}
+
+	words := make(map[string]uint64)
+	for i := range results {
+		if results[i].RO != nil && have[results[i].RO.Object] {
+			words[results[i].RO.Object], err = unsignedValue(&results[i])
+			if err != nil {
+				return fmt.Errorf("bit field source: %s; %v", results[i].RO.Object, err)
+			}
+		}
+	}
+
+	for _, fo := range bfs.fields {
+		param := fo.ro.Parameter
+		if param == "" {
+			param = fo.devObj.Name
+		}
+
+		raw, ok := values[param]
+		if !ok {
+			continue
+		}
+
+		v, err := bitFieldValue(fo, raw)
+		if err != nil {
+			return NewBadParameterError(fmt.Sprintf("parameter: %s; %v", param, err))
+		}
+
+		word, ok := words[fo.field.source]
+		if !ok {
+			return fmt.Errorf("no result for bit field source: %s", fo.field.source)
+		}
+
+		mask := fo.field.mask() << fo.field.offset
+		words[fo.field.source] = word&^mask | v<<fo.field.offset
+	}
+
+	setReqs := make([]CommandRequest, 0, len(reqs))
+	setValues := make(map[string]uint64, len(reqs))
+	for _, req := range reqs {
+		req.RO.Operation = "set"
+		setReqs = append(setReqs, req)
+		setValues[req.RO.Parameter] = words[req.RO.Object]
+	}
+
+	b, err := json.Marshal(setValues)
+	if err != nil {
+		return err
+	}
+
+	_, err = handleCommands(ctx, *d, setReqs, string(b))
+	return err
+}
+
+// bitFieldValue returns the value to be written to a bit field, given its
+// JSON decoded parameter value.
+func bitFieldValue(fo fieldOp, raw interface{}) (uint64, error) {
+	var s string
+	switch raw := raw.(type) {
+	case string:
+		s = raw
+	case json.Number:
+		s = raw.String()
+	case bool:
+		s = strconv.FormatBool(raw)
+	default:
+		return 0, fmt.Errorf("value must be a string, number or bool")
+	}
+
+	t := strings.ToLower(fo.devObj.Properties.Value.Type)
+	if t == "bool" || t == "boolean" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid bool: %s", s)
+		}
+
+		if b {
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || v > fo.field.mask() {
+		return 0, fmt.Errorf("value: %s doesn't fit in %d bits", s, fo.field.length)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,235 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+// registerDriver is a ProtocolDriver which holds Uint16 registers.
+type registerDriver struct {
+	registers map[string]uint16
+	writes    int
+}
+
+func (r *registerDriver) DisconnectDevice(address *models.Addressable) error {
+	return nil
+}
+
+func (r *registerDriver) Initialize(s *Service, lc logger.LoggingClient, asyncCh <-chan *CommandResult) error {
+	return nil
+}
+
+func (r *registerDriver) HandleCommands(ctx context.Context, d models.Device, reqs []CommandRequest, params string) ([]CommandResult, error) {
+	var values map[string]uint16
+	if params != "" {
+		if err := json.Unmarshal([]byte(params), &values); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []CommandResult
+	for i := range reqs {
+		ro := reqs[i].RO
+		if ro.Operation == "set" {
+			r.registers[ro.Object] = values[ro.Parameter]
+			r.writes++
+			continue
+		}
+
+		results = append(results, *NewUint16Result(&ro, nil, 0, r.registers[ro.Object]))
+	}
+
+	return results, nil
+}
+
+func (r *registerDriver) Stop(force bool) error {
+	return nil
+}
+
+func bitFieldObject(name string, typ string, offset string, length string) models.DeviceObject {
+	attrs := map[string]interface{}{BitSourceAttribute: "status", BitOffsetAttribute: offset}
+	if length != "" {
+		attrs[BitLengthAttribute] = length
+	}
+
+	do := models.DeviceObject{Name: name, Attributes: attrs}
+	do.Properties.Value.Type = typ
+	return do
+}
+
+func bitFieldObjects() map[string]models.DeviceObject {
+	status := models.DeviceObject{Name: "status"}
+	status.Properties.Value.Type = "Uint16"
+
+	return map[string]models.DeviceObject{
+		"status": status,
+		"alarm":  bitFieldObject("alarm", "Bool", "0", ""),
+		"mode":   bitFieldObject("mode", "Uint8", "4", "3"),
+	}
+}
+
+func TestBitFieldOf(t *testing.T) {
+	var tests = []struct {
+		name     string
+		do       models.DeviceObject
+		expected bitField
+		valid    bool
+	}{
+		{"Bool", bitFieldObject("alarm", "Bool", "3", ""), bitField{"status", 3, 1}, true},
+		{"Enum", bitFieldObject("mode", "Uint8", "4", "3"), bitField{"status", 4, 3}, true},
+		{"Long bool", bitFieldObject("alarm", "Bool", "0", "2"), bitField{}, false},
+		{"Too long for type", bitFieldObject("mode", "Uint8", "0", "9"), bitField{}, false},
+		{"Signed type", bitFieldObject("mode", "Int8", "0", "3"), bitField{}, false},
+		{"No offset", bitFieldObject("mode", "Uint8", "", "3"), bitField{}, false},
+		{"Zero length", bitFieldObject("mode", "Uint8", "0", "0"), bitField{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok, err := bitFieldOf(&tt.do)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("bitFieldOf: expected error")
+				}
+				return
+			}
+
+			if err != nil || !ok || f != tt.expected {
+				t.Errorf("bitFieldOf: expected: %v, got: %v, %v", tt.expected, f, err)
+			}
+		})
+	}
+
+	if _, ok, err := bitFieldOf(&models.DeviceObject{Name: "status"}); ok || err != nil {
+		t.Errorf("bitFieldOf: plain device object is a bit field")
+	}
+}
+
+func TestValidateBitFields(t *testing.T) {
+	objs := bitFieldObjects()
+	signed := objs["status"]
+	signed.Name = "signed"
+	signed.Properties.Value.Type = "Int16"
+	wide := bitFieldObject("wide", "Uint16", "8", "10")
+	orphan := bitFieldObject("orphan", "Bool", "0", "")
+	orphan.Attributes.(map[string]interface{})[BitSourceAttribute] = "missing"
+	fromSigned := bitFieldObject("fromSigned", "Bool", "0", "")
+	fromSigned.Attributes.(map[string]interface{})[BitSourceAttribute] = "signed"
+
+	problems := validateBitFields([]models.DeviceObject{
+		objs["status"], objs["alarm"], objs["mode"], signed, wide, orphan, fromSigned,
+	})
+
+	if len(problems) != 3 || problems["wide"] == "" || problems["orphan"] == "" || problems["fromSigned"] == "" {
+		t.Errorf("validateBitFields: expected problems with wide, orphan and fromSigned, got: %v", problems)
+	}
+}
+
+func TestBitFieldRead(t *testing.T) {
+	driver := &registerDriver{registers: map[string]uint16{"status": 0x0051}}
+	svc = &Service{c: &Config{}, proto: driver}
+	objs := bitFieldObjects()
+
+	reqs := []CommandRequest{
+		{RO: models.ResourceOperation{Object: "alarm"}, DeviceObject: objs["alarm"]},
+		{RO: models.ResourceOperation{Object: "mode", Mappings: map[string]string{"5": "auto"}}, DeviceObject: objs["mode"]},
+	}
+
+	reqs, bfs, err := bitFieldRequests(reqs, objs, true)
+	if err != nil || len(reqs) != 1 || reqs[0].RO.Object != "status" {
+		t.Fatalf("bitFieldRequests: expected a single status request, got: %v, %v", reqs, err)
+	}
+
+	results, err := handleCommands(context.Background(), models.Device{Name: "dev"}, reqs, "")
+	if err != nil {
+		t.Fatalf("handleCommands: failed: %v", err)
+	}
+
+	results, err = bfs.extract(results)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("extract: expected 2 results, got: %v, %v", results, err)
+	}
+
+	if alarm, err := results[0].BoolValue(); err != nil || !alarm || results[0].RO.Object != "alarm" {
+		t.Errorf("extract: expected alarm true, got: %v, %v", alarm, err)
+	}
+
+	if mode, err := results[1].Uint8Value(); err != nil || mode != 5 {
+		t.Errorf("extract: expected mode 5, got: %d, %v", mode, err)
+	}
+
+	if v := mapValue(results[1].RO, results[1].toString()); v != "auto" {
+		t.Errorf("extract: expected mapped mode auto, got: %s", v)
+	}
+}
+
+func TestBitFieldWrite(t *testing.T) {
+	driver := &registerDriver{registers: map[string]uint16{"status": 0xFF01}}
+	svc = &Service{c: &Config{}, proto: driver}
+	objs := bitFieldObjects()
+
+	reqs := []CommandRequest{
+		{RO: models.ResourceOperation{Object: "alarm", Parameter: "alarm"}, DeviceObject: objs["alarm"]},
+		{RO: models.ResourceOperation{Object: "mode", Parameter: "mode"}, DeviceObject: objs["mode"]},
+	}
+
+	reqs, bfs, err := bitFieldRequests(reqs, objs, false)
+	if err != nil || len(reqs) != 0 {
+		t.Fatalf("bitFieldRequests: expected no requests, got: %v, %v", reqs, err)
+	}
+
+	d := &models.Device{Name: "dev"}
+	err = bfs.write(context.Background(), d, objs, `{"alarm":false,"mode":"6"}`)
+	if err != nil {
+		t.Fatalf("write: failed: %v", err)
+	}
+
+	if driver.registers["status"] != 0xFF60 || driver.writes != 1 {
+		t.Errorf("write: expected status: 0xff60 in one write, got: %#x in %d", driver.registers["status"], driver.writes)
+	}
+
+	err = bfs.write(context.Background(), d, objs, `{"mode":8}`)
+	if ce, ok := err.(CommandError); !ok || ce.Code != 400 {
+		t.Errorf("write: expected bad parameter error, got: %v", err)
+	}
+
+	if driver.registers["status"] != 0xFF60 {
+		t.Errorf("write: status changed by failed write: %#x", driver.registers["status"])
+	}
+}
+
+func TestExecuteCommandBitFieldPut(t *testing.T) {
+	driver := &registerDriver{registers: map[string]uint16{"status": 0x0001}}
+	svc = &Service{c: &Config{Device: DeviceInfo{MaxCmdOps: 128}}, lc: logger.NewClient("bitfields_test", false, ""), proto: driver}
+	rc = newReadingCache()
+
+	objs := bitFieldObjects()
+	pc = &profileCache{
+		objects: map[string]map[string]models.DeviceObject{"dev": objs},
+		commands: map[string]map[string]map[string][]models.ResourceOperation{
+			"dev": {"mode": {"set": {{Object: "mode", Parameter: "mode"}}}},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	executeCommand(context.Background(), rr, &models.Device{Name: "dev"}, "mode", http.MethodPut, `{"mode":"3"}`, commandOptions{})
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("executeCommand: expected status: %d, got: %d %s", http.StatusNoContent, rr.Code, rr.Body.String())
+	}
+
+	if driver.registers["status"] != 0x0031 || driver.writes != 1 {
+		t.Errorf("executeCommand: expected status: 0x31 in one write, got: %#x in %d", driver.registers["status"], driver.writes)
+	}
+}
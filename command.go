@@ -7,12 +7,10 @@
 package device
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
-	"time"
 
 	"github.com/edgexfoundry/edgex-go/pkg/models"
 	"github.com/gorilla/mux"
@@ -28,28 +26,45 @@ func commandFunc(w http.ResponseWriter, r *http.Request) {
 	if svc.locked {
 		msg := fmt.Sprintf("%s is locked; %s %s", svc.Name, r.Method, r.URL)
 		svc.lc.Error(msg)
-		http.Error(w, msg, http.StatusLocked) // status=423
+		writeError(w, http.StatusLocked, msg, id, cmd) // status=423
 		return
 	}
 
 	// TODO - models.Device isn't thread safe currently
 	d := dc.DeviceById(id)
 	if d == nil {
-		// TODO: standardize error message format (use of prefix)
 		msg := fmt.Sprintf("dev: %s not found; %s %s", id, r.Method, r.URL)
 		svc.lc.Error(msg)
-		http.Error(w, msg, http.StatusNotFound) // status=404
+		writeError(w, http.StatusNotFound, msg, id, cmd) // status=404
 		return
 	}
 
 	if d.AdminState == "LOCKED" {
 		msg := fmt.Sprintf("%s is locked; %s %s", id, r.Method, r.URL)
 		svc.lc.Error(msg)
-		http.Error(w, msg, http.StatusLocked) // status=423
+		writeError(w, http.StatusLocked, msg, d.Name, cmd) // status=423
 		return
 	}
 
-	// TODO: need to mark device when operation in progress, so it can't be removed till completed
+	// the context is cancelled if the client disconnects, and bounds the
+	// time spent waiting for a serialized device as well as the command
+	ctx, cancel := commandContext(r.Context(), d.Name)
+	defer cancel()
+
+	// mark the device as in use, so it can't be removed till the command completes
+	op, err := ot.begin(ctx, d.Name)
+	if err == errDeviceRemoving {
+		msg := fmt.Sprintf("%s %v; %s %s", id, err, r.Method, r.URL)
+		svc.lc.Error(msg)
+		writeError(w, http.StatusConflict, msg, d.Name, cmd) // status=409
+		return
+	} else if err != nil {
+		msg := fmt.Sprintf("%s busy; %v; %s %s", id, err, r.Method, r.URL)
+		svc.lc.Error(msg)
+		writeError(w, commandErrorStatus(err), msg, d.Name, cmd) // status=504
+		return
+	}
+	defer op.end()
 
 	// NOTE: as currently implemented, CommandExists checks the existence of a deviceprofile
 	// *resource* name, not a *command* name! A deviceprofile's command section is only used
@@ -60,20 +75,25 @@ func commandFunc(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		msg := fmt.Sprintf("internal error; dev: %s not found in cache; %s %s", id, r.Method, r.URL)
 		svc.lc.Error(msg)
-		http.Error(w, msg, http.StatusInternalServerError) // status=500
+		writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
 		return
 	}
 
 	if !exists {
 		msg := fmt.Sprintf("%s for dev: %s not found; %s %s", cmd, id, r.Method, r.URL)
 		svc.lc.Error(msg)
-		http.Error(w, msg, http.StatusNotFound) // status=404
+		writeError(w, http.StatusNotFound, msg, d.Name, cmd) // status=404
 		return
 	}
 
 	defer r.Body.Close()
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
+	body, err := readBody(r)
+	if err == errRequestTooLarge {
+		msg := fmt.Sprintf("request body exceeds %d bytes; %s %s", maxRequestSize(), r.Method, r.URL)
+		svc.lc.Error(msg)
+		writeError(w, http.StatusRequestEntityTooLarge, msg, d.Name, cmd) // status=413
+		return
+	} else if err != nil {
 		msg := fmt.Sprintf("commandFunc: error reading request body for: %s %s", r.Method, r.URL)
 		svc.lc.Error(msg)
 	}
@@ -81,11 +101,19 @@ func commandFunc(w http.ResponseWriter, r *http.Request) {
 	if len(body) == 0 && r.Method == http.MethodPut {
 		msg := fmt.Sprintf("no request body provided; %s %s", r.Method, r.URL)
 		svc.lc.Error(msg)
-		http.Error(w, msg, http.StatusBadRequest) // status=400
+		writeError(w, http.StatusBadRequest, msg, d.Name, cmd) // status=400
 		return
 	}
 
-	executeCommand(w, d, cmd, r.Method, string(body))
+	opts, err := parseCommandOptions(r.URL.Query())
+	if err != nil {
+		msg := fmt.Sprintf("%v; %s %s", err, r.Method, r.URL)
+		svc.lc.Error(msg)
+		writeError(w, http.StatusBadRequest, msg, d.Name, cmd) // status=400
+		return
+	}
+
+	executeCommand(withOperation(ctx, op), w, d, cmd, r.Method, string(body), opts)
 }
 
 func commandAllFunc(w http.ResponseWriter, r *http.Request) {
@@ -96,7 +124,7 @@ func commandAllFunc(w http.ResponseWriter, r *http.Request) {
 	if svc.locked {
 		msg := fmt.Sprintf("%s is locked; %s %s", svc.Name, r.Method, r.URL)
 		svc.lc.Error(msg)
-		http.Error(w, msg, http.StatusLocked) // status=423
+		writeError(w, http.StatusLocked, msg, "", vars["command"]) // status=423
 		return
 	}
 
@@ -107,7 +135,6 @@ func commandAllFunc(w http.ResponseWriter, r *http.Request) {
 	// loop thru all existing devices:
 	// if devices.deviceBy(id).locked --> return http.StatusLocked; cache access needs to be sync'd
 	// TODO: add check for device-not-found; Java code doesn't check this
-	// TODO: need to mark device when operation in progress, so it can't be removed till completed...
 	// if commandExists == false --> return http.StatusNotFound (404);
 	//    (in Java, <proto>Handler implements commandExists, which delegates to the ProfileStore
 	//    executeCommand
@@ -118,22 +145,43 @@ func commandAllFunc(w http.ResponseWriter, r *http.Request) {
 	//      - formats reading(s) into an event, sends to core-data, return result
 }
 
-func executeCommand(w http.ResponseWriter, d *models.Device, cmd string, method string, args string) {
+func executeCommand(ctx context.Context, w http.ResponseWriter, d *models.Device, cmd string, method string, args string, opts commandOptions) {
 	readings := make([]models.Reading, 0, svc.c.Device.MaxCmdOps)
 
 	// make ResourceOperations
 	ops, err := pc.GetResourceOperations(d.Name, cmd, method)
 	if err != nil {
 		svc.lc.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusNotFound) // status=404
+		writeError(w, http.StatusNotFound, err.Error(), d.Name, cmd) // status=404
+		return
+	}
+
+	ops, err = pc.resolveResourceChains(d.Name, cmd, method, ops)
+	if err != nil {
+		svc.lc.Error(err.Error())
+		writeError(w, http.StatusInternalServerError, err.Error(), d.Name, cmd) // status=500
 		return
 	}
 
+	if method == http.MethodPut {
+		// the reverse of GET, which converts units then maps values
+		args, err = unmapParams(ops, args)
+		if err == nil {
+			args, err = unconvertParams(d.Name, ops, args)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("invalid parameters for dev: %s cmd: %s method: %s; %v", d.Name, cmd, method, err)
+			svc.lc.Error(msg)
+			writeError(w, http.StatusBadRequest, msg, d.Name, cmd) // status=400
+			return
+		}
+	}
+
 	if len(ops) > svc.c.Device.MaxCmdOps {
 		msg := fmt.Sprintf("MaxCmdOps (%d) execeeded for dev: %s cmd: %s method: %s",
 			svc.c.Device.MaxCmdOps, d.Name, cmd, method)
 		svc.lc.Error(msg)
-		http.Error(w, msg, http.StatusInternalServerError) // status=500
+		writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
 		return
 	}
 
@@ -141,7 +189,7 @@ func executeCommand(w http.ResponseWriter, d *models.Device, cmd string, method
 	if devObjs == nil {
 		msg := fmt.Sprintf("internal error; no devObjs for dev: %s; %s %s", d.Name, cmd, method)
 		svc.lc.Error(msg)
-		http.Error(w, msg, http.StatusInternalServerError) // status=500
+		writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
 		return
 	}
 
@@ -151,16 +199,12 @@ func executeCommand(w http.ResponseWriter, d *models.Device, cmd string, method
 		objName := op.Object
 		svc.lc.Debug(fmt.Sprintf("deviceObject: %s", objName))
 
-		// TODO: add recursive support for resource command chaining. This occurs when a
-		// deviceprofile resource command operation references another resource command
-		// instead of a device resource (see BoschXDK for reference).
-
 		devObj, ok := devObjs[objName]
 
 		svc.lc.Debug(fmt.Sprintf("deviceObject: %v", devObj))
 		if !ok {
 			msg := fmt.Sprintf("no devobject: %s for dev: %s cmd: %s method: %s", objName, d.Name, cmd, method)
-			http.Error(w, msg, http.StatusInternalServerError) // status=500
+			writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
 			return
 		}
 
@@ -168,70 +212,160 @@ func executeCommand(w http.ResponseWriter, d *models.Device, cmd string, method
 		reqs[i].DeviceObject = devObj
 	}
 
-	results, err := svc.proto.HandleCommands(*d, reqs, args)
+	// bit fields are read from, or written to, their source device objects
+	reqs, bfs, err := bitFieldRequests(reqs, devObjs, method == http.MethodGet)
 	if err != nil {
-		msg := fmt.Sprintf("HandleCommands error for dev: %s cmd: %s method: %s", d.Name, cmd, method)
-		http.Error(w, msg, http.StatusInternalServerError) // status=500
+		msg := fmt.Sprintf("%v; dev: %s cmd: %s method: %s", err, d.Name, cmd, method)
+		svc.lc.Error(msg)
+		writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
 		return
 	}
 
+	if method == http.MethodPut {
+		err = bfs.write(ctx, d, devObjs, args)
+		if err != nil {
+			msg := fmt.Sprintf("error writing bit fields for dev: %s cmd: %s method: %s; %v", d.Name, cmd, method, err)
+			svc.lc.Error(msg)
+			writeError(w, commandErrorStatus(err), msg, d.Name, cmd)
+			return
+		}
+	}
+
+	var results []CommandResult
+	if len(reqs) > 0 {
+		results, err = handleCommands(ctx, *d, reqs, args)
+		if err != nil {
+			msg := fmt.Sprintf("HandleCommands error for dev: %s cmd: %s method: %s; %v", d.Name, cmd, method, err)
+			svc.lc.Error(msg)
+			writeError(w, commandErrorStatus(err), msg, d.Name, cmd)
+			return
+		}
+	}
+
+	// readings for secondary device objects are included in the same event,
+	// and if not already returned by the driver, are read separately
+	if method == http.MethodGet {
+		results, err = bfs.extract(results)
+		if err != nil {
+			msg := fmt.Sprintf("%v; dev: %s cmd: %s method: %s", err, d.Name, cmd, method)
+			svc.lc.Error(msg)
+			writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
+			return
+		}
+
+		secReqs, err := secondaryRequests(results, devObjs)
+		if err != nil {
+			msg := fmt.Sprintf("%v; dev: %s cmd: %s method: %s", err, d.Name, cmd, method)
+			svc.lc.Error(msg)
+			writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
+			return
+		}
+
+		if len(secReqs) > 0 {
+			secResults, err := handleCommands(ctx, *d, secReqs, "")
+			if err != nil {
+				msg := fmt.Sprintf("HandleCommands error reading secondaries for dev: %s cmd: %s method: %s; %v", d.Name, cmd, method, err)
+				svc.lc.Error(msg)
+				writeError(w, commandErrorStatus(err), msg, d.Name, cmd)
+				return
+			}
+
+			results = append(results, secResults...)
+		}
+	}
+
 	var transformsOK bool = true
 
 	for _, cr := range results {
 		// get the device resource associated with the rsp.RO
 		do := pc.getDeviceObject(d, cr.RO)
+		if do == nil {
+			msg := fmt.Sprintf("Handler for dev: %s cmd: %s method: %s; no device resource: %s", d.Name, cmd, method, cr.RO.Object)
+			svc.lc.Error(msg)
+			writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
+			return
+		}
 
 		ok := cr.TransformResult(do.Properties.Value)
 		if !ok {
 			transformsOK = false
 		}
 
-		// TODO: handle Mappings (part of RO)
+		quality, err := validateResult(&cr, d.Name, do)
+		if err != nil {
+			msg := fmt.Sprintf("Handler for dev: %s cmd: %s method: %s; %v", d.Name, cmd, method, err)
+			svc.lc.Error(msg)
+			writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
+			return
+		}
+
+		err = cr.convertUnits(do)
+		if err != nil {
+			msg := fmt.Sprintf("Handler for dev: %s cmd: %s method: %s; %v", d.Name, cmd, method, err)
+			svc.lc.Error(msg)
+			writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
+			return
+		}
 
-		// TODO: the Java SDK supports a RO secondary device resource(object).
-		// If defined, then a RO result will generate a reading for the
-		// secondary object. As this use case isn't defined and/or used in
-		// any of the existing Java device services, this concept hasn't
-		// been implemened in gxds. TBD at the devices f2f whether this
-		// be killed completely.
+		reading := newReading(&cr, d.Name, do)
+		reading.Value = mapValue(cr.RO, reading.Value)
+
+		err = checkValueLen(reading, cr.Type)
+		if err != nil {
+			msg := fmt.Sprintf("Handler for dev: %s cmd: %s method: %s; %v", d.Name, cmd, method, err)
+			svc.lc.Error(msg)
+			writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
+			return
+		}
 
-		reading := cr.Reading(d.Name, do.Name)
 		readings = append(readings, *reading)
+		if quality != "" {
+			readings = append(readings, qualityReading(reading, quality))
+		}
 
 		svc.lc.Debug(fmt.Sprintf("dev: %s RO: %v reading: %v", d.Name, cr.RO, reading))
 	}
 
-	// push to Core Data
+	// push to Core Data, unless disabled for e.g. diagnostic reads,
+	// which are only recorded locally and bypass aggregation
 	event := &models.Event{Device: d.Name, Readings: readings}
-	event.Origin = time.Now().UnixNano() / int64(time.Millisecond)
-	go sendEvent(event)
+	event.Origin = now()
+	if !opts.pushEvent {
+		recordEvent(event)
+	} else if e := exportEvent(event); e != nil {
+		go sendEvent(e)
+	}
 
 	// TODO: the 'all' form of the endpoint returns 200 if a transform
 	// overflow or assertion trips...
 	if !transformsOK {
 		msg := fmt.Sprintf("Transform failed for dev: %s cmd: %s method: %s", d.Name, cmd, method)
-		http.Error(w, msg, http.StatusInternalServerError) // status=500
+		writeError(w, http.StatusInternalServerError, msg, d.Name, cmd) // status=500
+		return
 	}
 
-	// TODO: enforce config.MaxCmdValueLen; need to include overhead for
-	// the rest of the Reading JSON + Event JSON length?  Should there be
-	// a separate JSON body max limit for retvals & command parameters?
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(event)
+	writeEvent(w, event, opts)
 }
 
 func initCommand() {
 	svc.lc.Debug("initCommand called")
 
 	sr := svc.r.PathPrefix("/device").Subrouter()
+	// the by-name route must precede the command route, which it also matches
+	sr.HandleFunc("/name/{name}", deviceCommandsFunc).Methods(http.MethodGet)
+	sr.HandleFunc("/{id}", deviceCommandsFunc).Methods(http.MethodGet)
+	sr.HandleFunc("/{id}/reading/{resource}", lastReadingFunc).Methods(http.MethodGet)
 	sr.HandleFunc("/{id}/{command}", commandFunc).Methods(http.MethodGet, http.MethodPut)
 	sr.HandleFunc("/all/{command}", commandAllFunc).Methods(http.MethodGet, http.MethodPut)
 }
 
+// sendEvent pushes the given event to Core Data. Events with more than
+// ReadMaxLimit readings are split into several events.
 func sendEvent(event *models.Event) {
-	_, err := svc.ec.Add(event)
-	if err != nil {
-		svc.lc.Error(fmt.Sprintf("Failed to push event for device %s: %s", event.Device, err))
+	for _, e := range splitEvent(event, svc.c.Service.ReadMaxLimit) {
+		_, err := svc.ec.Add(e)
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("Failed to push event for device %s: %s", e.Device, err))
+		}
 	}
 }
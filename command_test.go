@@ -7,6 +7,8 @@
 package device
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -14,6 +16,7 @@ import (
 	"testing"
 
 	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
 )
 
 const (
@@ -39,11 +42,16 @@ func TestCommandServiceLocked(t *testing.T) {
 			status, http.StatusLocked)
 	}
 
-	body := strings.TrimSpace(rr.Body.String())
+	var rsp ErrorResponse
+	err := json.NewDecoder(rr.Body).Decode(&rsp)
+	if err != nil {
+		t.Fatalf("ServiceLocked: handler returned invalid error response: %v", err)
+	}
+
 	expected := deviceCommandTest + " is locked; GET " + v1Device + "/nil/nil"
 
-	if body != expected {
-		t.Errorf("ServiceLocked: handler returned wrong body:\nexpected: %s\ngot:      %s", expected, body)
+	if rsp.Code != http.StatusLocked || rsp.Message != expected {
+		t.Errorf("ServiceLocked: handler returned wrong error:\nexpected: %s\ngot:      %v", expected, rsp)
 	}
 }
 
@@ -67,11 +75,16 @@ func TestCommandNoDevice(t *testing.T) {
 			status, http.StatusNotFound)
 	}
 
-	body := strings.TrimSpace(rr.Body.String())
+	var rsp ErrorResponse
+	err := json.NewDecoder(rr.Body).Decode(&rsp)
+	if err != nil {
+		t.Fatalf("NoDevice: handler returned invalid error response: %v", err)
+	}
+
 	expected := "dev: " + badDeviceId + " not found; GET " + v1Device + "/" + badDeviceId + "/" + testCmd
 
-	if body != expected {
-		t.Errorf("ServiceLocked: handler returned wrong body:\nexpected: %s\ngot:      %s", expected, body)
+	if rsp.Code != http.StatusNotFound || rsp.Message != expected || rsp.Device != badDeviceId || rsp.Command != testCmd {
+		t.Errorf("NoDevice: handler returned wrong error:\nexpected: %s\ngot:      %v", expected, rsp)
 	}
 }
 
@@ -129,3 +142,40 @@ func TestCommandDeviceLocked(t *testing.T) {
 	}
 	*/
 }
+
+// reloadingDriver is a registerDriver which removes the device resources
+// it reads from the profile cache, as if the profile were reloaded while
+// it handled the command.
+type reloadingDriver struct {
+	registerDriver
+}
+
+func (r *reloadingDriver) HandleCommands(ctx context.Context, d models.Device, reqs []CommandRequest, params string) ([]CommandResult, error) {
+	pc.mutex.Lock()
+	for i := range reqs {
+		delete(pc.objects[d.Name], reqs[i].RO.Object)
+	}
+	pc.mutex.Unlock()
+
+	return r.registerDriver.HandleCommands(ctx, d, reqs, params)
+}
+
+// Test a command whose device resource has disappeared from the profile
+// while it was in progress.
+func TestExecuteCommandMissingDeviceObject(t *testing.T) {
+	driver := &reloadingDriver{registerDriver{registers: map[string]uint16{"level": 7}}}
+	svc = &Service{c: &Config{Device: DeviceInfo{MaxCmdOps: 128}}, lc: logger.NewClient("command_test", false, ""), proto: driver}
+	pc = &profileCache{
+		objects: map[string]map[string]models.DeviceObject{"dev": {"level": {Name: "level"}}},
+		commands: map[string]map[string]map[string][]models.ResourceOperation{
+			"dev": {"level": {"get": {{Operation: "get", Object: "level", Parameter: "level"}}}},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	executeCommand(context.Background(), rr, &models.Device{Name: "dev"}, "level", http.MethodGet, "", commandOptions{})
+	if rr.Code != http.StatusInternalServerError || !strings.Contains(rr.Body.String(), "no device resource: level") {
+		t.Errorf("executeCommand: expected status: %d naming the missing resource, got: %d %s",
+			http.StatusInternalServerError, rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,102 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+const (
+	// PushEventParam is the command query parameter which specifies
+	// whether the resulting event is pushed to Core Data ("yes" or "no").
+	PushEventParam = "ds-pushevent"
+	// ReturnEventParam is the command query parameter which specifies
+	// whether the resulting event is returned in the response ("yes" or
+	// "no"). If not, only the status is returned.
+	ReturnEventParam = "ds-returnevent"
+	// FormatParam is the command query parameter which specifies the
+	// response format; either FormatEvent or FormatMap.
+	FormatParam = "ds-format"
+
+	// FormatEvent returns the full event.
+	FormatEvent = "event"
+	// FormatMap returns a JSON object which maps reading names to values.
+	FormatMap = "map"
+)
+
+// commandOptions holds the options for a command, set by query parameters.
+type commandOptions struct {
+	pushEvent   bool
+	returnEvent bool
+	format      string
+}
+
+// parseCommandOptions returns the commandOptions set by the given query.
+func parseCommandOptions(query url.Values) (commandOptions, error) {
+	opts := commandOptions{pushEvent: true, returnEvent: true, format: FormatEvent}
+
+	var err error
+	opts.pushEvent, err = yesNoParam(query, PushEventParam, opts.pushEvent)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.returnEvent, err = yesNoParam(query, ReturnEventParam, opts.returnEvent)
+	if err != nil {
+		return opts, err
+	}
+
+	if f := query.Get(FormatParam); f != "" {
+		if f != FormatEvent && f != FormatMap {
+			return opts, fmt.Errorf("invalid %s: %s; expected %s or %s", FormatParam, f, FormatEvent, FormatMap)
+		}
+		opts.format = f
+	}
+
+	return opts, nil
+}
+
+func yesNoParam(query url.Values, name string, def bool) (bool, error) {
+	switch query.Get(name) {
+	case "":
+		return def, nil
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	default:
+		return def, fmt.Errorf("invalid %s: %s; expected yes or no", name, query.Get(name))
+	}
+}
+
+// writeEvent writes the response to a successful command, as specified
+// by the given options.
+func writeEvent(w http.ResponseWriter, event *models.Event, opts commandOptions) {
+	if !opts.returnEvent {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if opts.format == FormatMap {
+		values := make(map[string]string, len(event.Readings))
+		for _, r := range event.Readings {
+			values[r.Name] = r.Value
+		}
+
+		json.NewEncoder(w).Encode(values)
+		return
+	}
+
+	json.NewEncoder(w).Encode(event)
+}
@@ -0,0 +1,80 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+func TestParseCommandOptions(t *testing.T) {
+	var tests = []struct {
+		name  string
+		query string
+		opts  commandOptions
+		err   bool
+	}{
+		{"defaults", "", commandOptions{true, true, FormatEvent}, false},
+		{"no push", "ds-pushevent=no", commandOptions{false, true, FormatEvent}, false},
+		{"status only", "ds-returnevent=no", commandOptions{true, false, FormatEvent}, false},
+		{"map", "ds-format=map&ds-pushevent=yes", commandOptions{true, true, FormatMap}, false},
+		{"invalid push", "ds-pushevent=false", commandOptions{}, true},
+		{"invalid format", "ds-format=xml", commandOptions{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			opts, err := parseCommandOptions(query)
+			if tt.err {
+				if err == nil {
+					t.Errorf("parseCommandOptions: invalid query accepted")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("parseCommandOptions: unexpected error: %v", err)
+			} else if opts != tt.opts {
+				t.Errorf("parseCommandOptions: expected: %v, got: %v", tt.opts, opts)
+			}
+		})
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	event := &models.Event{Device: "dev", Readings: []models.Reading{{Name: "Switch", Value: "ON"}, {Name: "Level", Value: "42"}}}
+
+	rr := httptest.NewRecorder()
+	writeEvent(rr, event, commandOptions{returnEvent: false})
+	if rr.Code != http.StatusNoContent || rr.Body.Len() != 0 {
+		t.Errorf("writeEvent: expected status only, got: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	writeEvent(rr, event, commandOptions{returnEvent: true, format: FormatMap})
+
+	var values map[string]string
+	err := json.NewDecoder(rr.Body).Decode(&values)
+	if err != nil || len(values) != 2 || values["Switch"] != "ON" || values["Level"] != "42" {
+		t.Errorf("writeEvent: wrong map returned: %v; err: %v", values, err)
+	}
+
+	rr = httptest.NewRecorder()
+	writeEvent(rr, event, commandOptions{returnEvent: true, format: FormatEvent})
+
+	var e models.Event
+	err = json.NewDecoder(rr.Body).Decode(&e)
+	if err != nil || e.Device != "dev" || len(e.Readings) != 2 {
+		t.Errorf("writeEvent: wrong event returned: %v; err: %v", e, err)
+	}
+}
@@ -8,9 +8,10 @@ package device
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/edgexfoundry/edgex-go/pkg/models"
 )
@@ -56,6 +57,43 @@ const (
 	// Float64 indicates that the result is a float64 that
 	// is stored in CommandResult's NumericRes member.
 	Float64
+	// Binary indicates that the result is a byte slice, with an
+	// optional media type, stored in CommandResult's BinaryResult
+	// and MediaType members.
+	Binary
+	// Uint8Array indicates that the result is a []uint8 that
+	// is stored in CommandResult's NumericRes member.
+	Uint8Array
+	// Uint16Array indicates that the result is a []uint16 that
+	// is stored in CommandResult's NumericRes member.
+	Uint16Array
+	// Uint32Array indicates that the result is a []uint32 that
+	// is stored in CommandResult's NumericRes member.
+	Uint32Array
+	// Uint64Array indicates that the result is a []uint64 that
+	// is stored in CommandResult's NumericRes member.
+	Uint64Array
+	// Int8Array indicates that the result is a []int8 that
+	// is stored in CommandResult's NumericRes member.
+	Int8Array
+	// Int16Array indicates that the result is a []int16 that
+	// is stored in CommandResult's NumericRes member.
+	Int16Array
+	// Int32Array indicates that the result is a []int32 that
+	// is stored in CommandResult's NumericRes member.
+	Int32Array
+	// Int64Array indicates that the result is a []int64 that
+	// is stored in CommandResult's NumericRes member.
+	Int64Array
+	// Float32Array indicates that the result is a []float32 that
+	// is stored in CommandResult's NumericRes member.
+	Float32Array
+	// Float64Array indicates that the result is a []float64 that
+	// is stored in CommandResult's NumericRes member.
+	Float64Array
+	// JSON indicates that the result is a JSON encoded value,
+	// stored in CommandResult's StringResult member.
+	JSON
 )
 
 type CommandResult struct {
@@ -70,175 +108,432 @@ type CommandResult struct {
 	VDR *models.ValueDescriptor
 	// Origin is an int64 value which indicates the time the reading
 	// contained in the CommandResult was read by the ProtocolDriver
-	// instance.
+	// instance, in the precision returned by Service.Timestamp. If
+	// zero, the current time of the service Clock is used.
 	Origin int64
 	// Type is a ResultType value which indicates what type of
 	// result was returned from the ProtocolDriver instance in
 	// response to HandleCommand being called to handle a single
 	// ResourceOperation.
 	Type ResultType
-	// NumericResult is a byte slice with a maximum capacity of
-	// 64 bytes, used to hold a numeric result returned by a
-	// ProtocolDriver instance. The value can be converted to
-	// its native type by referring to the the value of ResType.
+	// NumericResult is a byte slice used to hold a numeric result, or
+	// the big-endian encoded elements of a numeric array result, returned
+	// by a ProtocolDriver instance. The value can be converted to its native
+	// type by referring to the the value of ResType.
 	NumericResult []byte
 	// StringResult is a string value returned as a result by a ProtocolDriver instance.
 	StringResult string
 	// BoolResult is a bool value returned as a result by a ProtocolDriver instance.
 	BoolResult bool
+	// BinaryResult is a binary value returned as a result by a ProtocolDriver instance.
+	BinaryResult []byte
+	// MediaType is the media type (e.g. "image/jpeg") of a BinaryResult.
+	MediaType string
 }
 
-func NewBoolResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value bool) (cr *CommandResult) {
-
-	// TODO: modify CommandResult to just store bool in NumericValue too...
-
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Bool, BoolResult: value}
-
-	fmt.Printf("result: %v\n", cr)
-	return
+var resultTypeNames = []string{
+	"Bool", "String", "Uint8", "Uint16", "Uint32", "Uint64", "Int8", "Int16", "Int32", "Int64",
+	"Float32", "Float64", "Binary", "Uint8Array", "Uint16Array", "Uint32Array", "Uint64Array",
+	"Int8Array", "Int16Array", "Int32Array", "Int64Array", "Float32Array", "Float64Array", "JSON",
 }
 
-func NewStringResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value string) (cr *CommandResult) {
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: String, StringResult: value}
+// String returns the name of the ResultType.
+func (t ResultType) String() string {
+	if t < 0 || int(t) >= len(resultTypeNames) {
+		return fmt.Sprintf("ResultType(%d)", int(t))
+	}
 
-	fmt.Printf("result: %v\n", cr)
-	return
+	return resultTypeNames[t]
 }
 
-// TODO: re-factor to get rid of duplicate code
+// NewCommandResult creates a CommandResult with the given value, inferring
+// its Type from the value's Go type. Supported types are bool, string, the
+// fixed-width integer and float types, []byte (Binary), slices of the other
+// numeric types, and json.RawMessage (JSON). An error is returned for any
+// other type.
+func NewCommandResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value interface{}) (*CommandResult, error) {
+	cr := &CommandResult{RO: ro, VDR: vdr, Origin: origin}
+
+	switch v := value.(type) {
+	case bool:
+		cr.Type = Bool
+		cr.BoolResult = v
+		return cr, nil
+	case string:
+		cr.Type = String
+		cr.StringResult = v
+		return cr, nil
+	case []byte:
+		cr.Type = Binary
+		cr.BinaryResult = v
+		return cr, nil
+	case json.RawMessage:
+		if !json.Valid(v) {
+			return nil, fmt.Errorf("invalid JSON result: %s", v)
+		}
+		cr.Type = JSON
+		cr.StringResult = string(v)
+		return cr, nil
+	case uint8:
+		cr.Type = Uint8
+	case uint16:
+		cr.Type = Uint16
+	case uint32:
+		cr.Type = Uint32
+	case uint64:
+		cr.Type = Uint64
+	case int8:
+		cr.Type = Int8
+	case int16:
+		cr.Type = Int16
+	case int32:
+		cr.Type = Int32
+	case int64:
+		cr.Type = Int64
+	case float32:
+		cr.Type = Float32
+	case float64:
+		cr.Type = Float64
+	case []uint16:
+		cr.Type = Uint16Array
+	case []uint32:
+		cr.Type = Uint32Array
+	case []uint64:
+		cr.Type = Uint64Array
+	case []int8:
+		cr.Type = Int8Array
+	case []int16:
+		cr.Type = Int16Array
+	case []int32:
+		cr.Type = Int32Array
+	case []int64:
+		cr.Type = Int64Array
+	case []float32:
+		cr.Type = Float32Array
+	case []float64:
+		cr.Type = Float64Array
+	default:
+		return nil, fmt.Errorf("unsupported result type: %T", value)
+	}
 
-// NewUint8Result creates a CommandResult of Type Uint8 with the given value.
-func NewUint8Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value uint8) (cr *CommandResult) {
 	buf := new(bytes.Buffer)
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Uint8}
 	err := binary.Write(buf, binary.BigEndian, value)
 	if err != nil {
-		fmt.Printf("binary.Write failed: %v\n", err)
+		return nil, err
 	}
 
 	cr.NumericResult = buf.Bytes()
-	return
+	return cr, nil
 }
 
-// NewUint16Result creates a CommandResult of Type Uint16 with the given value.
-func NewUint16Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value uint16) (cr *CommandResult) {
-	buf := new(bytes.Buffer)
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Uint16}
-	err := binary.Write(buf, binary.BigEndian, value)
+// newResult creates a CommandResult for a value of a type which is known to
+// be supported by NewCommandResult, so can't fail.
+func newResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value interface{}) *CommandResult {
+	cr, err := NewCommandResult(ro, vdr, origin, value)
 	if err != nil {
-		fmt.Printf("binary.Write failed: %v\n", err)
+		panic(err)
 	}
 
-	cr.NumericResult = buf.Bytes()
-	return
+	return cr
 }
 
-// NewUint32Result creates a CommandResult of Type Uint32 with the given value.
-func NewUint32Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value uint32) (cr *CommandResult) {
-	buf := new(bytes.Buffer)
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Uint32}
-	err := binary.Write(buf, binary.BigEndian, value)
-	if err != nil {
-		fmt.Printf("binary.Write failed: %v\n", err)
-	}
+// NewBoolResult creates a CommandResult of Type Bool with the given value.
+func NewBoolResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value bool) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
 
-	cr.NumericResult = buf.Bytes()
-	return
+// NewStringResult creates a CommandResult of Type String with the given value.
+func NewStringResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value string) *CommandResult {
+	return newResult(ro, vdr, origin, value)
 }
 
-// NewUint64Result creates a CommandResult of Type Uint64 with the given value.
-func NewUint64Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value uint64) (cr *CommandResult) {
-	buf := new(bytes.Buffer)
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Uint64}
-	err := binary.Write(buf, binary.BigEndian, value)
-	if err != nil {
-		fmt.Printf("binary.Write failed: %v\n", err)
-	}
+// NewUint8Result creates a CommandResult of Type Uint8 with the given value.
+func NewUint8Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value uint8) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
 
-	cr.NumericResult = buf.Bytes()
-	return
+// NewUint16Result creates a CommandResult of Type Uint16 with the given value.
+func NewUint16Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value uint16) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewUint32Result creates a CommandResult of Type Uint32 with the given value.
+func NewUint32Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value uint32) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewUint64Result creates a CommandResult of Type Uint64 with the given value.
+func NewUint64Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value uint64) *CommandResult {
+	return newResult(ro, vdr, origin, value)
 }
 
 // NewInt8Result creates a CommandResult of Type Int8 with the given value.
-func NewInt8Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value int8) (cr *CommandResult) {
-	buf := new(bytes.Buffer)
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Int8}
-	err := binary.Write(buf, binary.BigEndian, value)
+func NewInt8Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value int8) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewInt16Result creates a CommandResult of Type Int16 with the given value.
+func NewInt16Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value int16) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewInt32Result creates a CommandResult of Type Int32 with the given value.
+func NewInt32Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value int32) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewInt64Result creates a CommandResult of Type Int64 with the given value.
+func NewInt64Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value int64) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewFloat32Result creates a CommandResult of Type Float32 with the given value.
+func NewFloat32Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value float32) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewFloat64Result creates a CommandResult of Type Float64 with the given value.
+func NewFloat64Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value float64) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewBinaryResult creates a CommandResult of Type Binary with the given
+// value and media type. The media type may be empty if unknown.
+func NewBinaryResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value []byte, mediaType string) *CommandResult {
+	cr := newResult(ro, vdr, origin, value)
+	cr.MediaType = mediaType
+	return cr
+}
+
+// NewJSONResult creates a CommandResult of Type JSON with the JSON
+// encoding of the given value.
+func NewJSONResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value interface{}) (*CommandResult, error) {
+	b, err := json.Marshal(value)
 	if err != nil {
-		fmt.Printf("binary.Write failed: %v\n", err)
+		return nil, err
 	}
 
-	cr.NumericResult = buf.Bytes()
-	return
+	return NewCommandResult(ro, vdr, origin, json.RawMessage(b))
 }
 
-// NewInt16Result creates a CommandResult of Type Int16 with the given value.
-func NewInt16Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value int16) (cr *CommandResult) {
-	buf := new(bytes.Buffer)
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Int16}
-	err := binary.Write(buf, binary.BigEndian, value)
-	if err != nil {
-		fmt.Printf("binary.Write failed: %v\n", err)
+// NewUint8ArrayResult creates a CommandResult of Type Uint8Array with the given value.
+func NewUint8ArrayResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value []uint8) *CommandResult {
+	// []uint8 is inferred as Binary, so the array is stored explicitly
+	return &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Uint8Array, NumericResult: append([]byte(nil), value...)}
+}
+
+// NewUint16ArrayResult creates a CommandResult of Type Uint16Array with the given value.
+func NewUint16ArrayResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value []uint16) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewUint32ArrayResult creates a CommandResult of Type Uint32Array with the given value.
+func NewUint32ArrayResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value []uint32) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewUint64ArrayResult creates a CommandResult of Type Uint64Array with the given value.
+func NewUint64ArrayResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value []uint64) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewInt8ArrayResult creates a CommandResult of Type Int8Array with the given value.
+func NewInt8ArrayResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value []int8) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewInt16ArrayResult creates a CommandResult of Type Int16Array with the given value.
+func NewInt16ArrayResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value []int16) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewInt32ArrayResult creates a CommandResult of Type Int32Array with the given value.
+func NewInt32ArrayResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value []int32) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewInt64ArrayResult creates a CommandResult of Type Int64Array with the given value.
+func NewInt64ArrayResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value []int64) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewFloat32ArrayResult creates a CommandResult of Type Float32Array with the given value.
+func NewFloat32ArrayResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value []float32) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// NewFloat64ArrayResult creates a CommandResult of Type Float64Array with the given value.
+func NewFloat64ArrayResult(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value []float64) *CommandResult {
+	return newResult(ro, vdr, origin, value)
+}
+
+// arrayElementType returns the element type of an array ResultType,
+// and whether the type is an array type.
+func arrayElementType(t ResultType) (ResultType, bool) {
+	if t < Uint8Array || t > Float64Array {
+		return t, false
 	}
 
-	cr.NumericResult = buf.Bytes()
-	return
+	return Uint8 + (t - Uint8Array), true
 }
 
-// NewInt32Result creates a CommandResult of Type Int32 with the given value.
-func NewInt32Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value int32) (cr *CommandResult) {
-	buf := new(bytes.Buffer)
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Int32}
-	err := binary.Write(buf, binary.BigEndian, value)
+// ArrayValue returns the elements of an array result as a slice of the
+// element type, e.g. []int16 for an Int16Array result.
+func (cr *CommandResult) ArrayValue() (interface{}, error) {
+	elem, ok := arrayElementType(cr.Type)
+	if !ok {
+		return nil, fmt.Errorf("%v result is not an array", cr.Type)
+	}
+
+	n := len(cr.NumericResult) / (resultBits(elem) / 8)
+
+	var v interface{}
+	switch cr.Type {
+	case Uint8Array:
+		v = make([]uint8, n)
+	case Uint16Array:
+		v = make([]uint16, n)
+	case Uint32Array:
+		v = make([]uint32, n)
+	case Uint64Array:
+		v = make([]uint64, n)
+	case Int8Array:
+		v = make([]int8, n)
+	case Int16Array:
+		v = make([]int16, n)
+	case Int32Array:
+		v = make([]int32, n)
+	case Int64Array:
+		v = make([]int64, n)
+	case Float32Array:
+		v = make([]float32, n)
+	case Float64Array:
+		v = make([]float64, n)
+	}
+
+	err := binary.Read(bytes.NewReader(cr.NumericResult), binary.BigEndian, v)
 	if err != nil {
-		fmt.Printf("binary.Write failed: %v\n", err)
+		return nil, err
 	}
 
-	cr.NumericResult = buf.Bytes()
-	return
+	return v, nil
 }
 
-// NewInt64Result creates a CommandResult of Type Int64 with the given value.
-func NewInt64Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value int64) (cr *CommandResult) {
-	buf := new(bytes.Buffer)
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Int64}
-	err := binary.Write(buf, binary.BigEndian, value)
-	if err != nil {
-		fmt.Printf("binary.Write failed: %v\n", err)
+// checkType returns an error if the result isn't of the given type.
+func (cr *CommandResult) checkType(t ResultType) error {
+	if cr.Type != t {
+		return fmt.Errorf("%v result is not %v", cr.Type, t)
 	}
 
-	cr.NumericResult = buf.Bytes()
-	return
+	return nil
 }
 
-// NewFloat32Result creates a CommandResult of Type Float32 with the given value.
-func NewFloat32Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value float32) (cr *CommandResult) {
-	buf := new(bytes.Buffer)
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Float32}
-	err := binary.Write(buf, binary.BigEndian, value)
+// numericValue decodes a numeric result of the given type into v, which
+// must be a pointer to the corresponding fixed-width type.
+func (cr *CommandResult) numericValue(t ResultType, v interface{}) error {
+	err := cr.checkType(t)
 	if err != nil {
-		fmt.Printf("binary.Write failed: %v\n", err)
+		return err
 	}
 
-	cr.NumericResult = buf.Bytes()
-	return
+	return binary.Read(bytes.NewReader(cr.NumericResult), binary.BigEndian, v)
 }
 
-// NewFloat64Result creates a CommandResult of Type Float64 with the given value.
-func NewFloat64Result(ro *models.ResourceOperation, vdr *models.ValueDescriptor, origin int64, value float64) (cr *CommandResult) {
-	buf := new(bytes.Buffer)
-	cr = &CommandResult{RO: ro, VDR: vdr, Origin: origin, Type: Float64}
-	err := binary.Write(buf, binary.BigEndian, value)
+// BoolValue returns the value of a Bool result.
+func (cr *CommandResult) BoolValue() (bool, error) {
+	return cr.BoolResult, cr.checkType(Bool)
+}
+
+// StringValue returns the value of a String result.
+func (cr *CommandResult) StringValue() (string, error) {
+	return cr.StringResult, cr.checkType(String)
+}
+
+// Uint8Value returns the value of a Uint8 result.
+func (cr *CommandResult) Uint8Value() (uint8, error) {
+	var v uint8
+	err := cr.numericValue(Uint8, &v)
+	return v, err
+}
+
+// Uint16Value returns the value of a Uint16 result.
+func (cr *CommandResult) Uint16Value() (uint16, error) {
+	var v uint16
+	err := cr.numericValue(Uint16, &v)
+	return v, err
+}
+
+// Uint32Value returns the value of a Uint32 result.
+func (cr *CommandResult) Uint32Value() (uint32, error) {
+	var v uint32
+	err := cr.numericValue(Uint32, &v)
+	return v, err
+}
+
+// Uint64Value returns the value of a Uint64 result.
+func (cr *CommandResult) Uint64Value() (uint64, error) {
+	var v uint64
+	err := cr.numericValue(Uint64, &v)
+	return v, err
+}
+
+// Int8Value returns the value of a Int8 result.
+func (cr *CommandResult) Int8Value() (int8, error) {
+	var v int8
+	err := cr.numericValue(Int8, &v)
+	return v, err
+}
+
+// Int16Value returns the value of a Int16 result.
+func (cr *CommandResult) Int16Value() (int16, error) {
+	var v int16
+	err := cr.numericValue(Int16, &v)
+	return v, err
+}
+
+// Int32Value returns the value of a Int32 result.
+func (cr *CommandResult) Int32Value() (int32, error) {
+	var v int32
+	err := cr.numericValue(Int32, &v)
+	return v, err
+}
+
+// Int64Value returns the value of a Int64 result.
+func (cr *CommandResult) Int64Value() (int64, error) {
+	var v int64
+	err := cr.numericValue(Int64, &v)
+	return v, err
+}
+
+// Float32Value returns the value of a Float32 result.
+func (cr *CommandResult) Float32Value() (float32, error) {
+	var v float32
+	err := cr.numericValue(Float32, &v)
+	return v, err
+}
+
+// Float64Value returns the value of a Float64 result.
+func (cr *CommandResult) Float64Value() (float64, error) {
+	var v float64
+	err := cr.numericValue(Float64, &v)
+	return v, err
+}
+
+// BinaryValue returns the value of a Binary result.
+func (cr *CommandResult) BinaryValue() ([]byte, error) {
+	return cr.BinaryResult, cr.checkType(Binary)
+}
+
+// JSONValue decodes the value of a JSON result into v.
+func (cr *CommandResult) JSONValue(v interface{}) error {
+	err := cr.checkType(JSON)
 	if err != nil {
-		fmt.Printf("binary.Write failed: %v\n", err)
+		return err
 	}
 
-	cr.NumericResult = buf.Bytes()
-	return
+	return json.Unmarshal([]byte(cr.StringResult), v)
 }
 
-// TODO: add Get funcs for all basic types
-
 // Transform applies any transform attributes contained in the
 // CommandResult's ValueDescriptor to the result. If the transform
 // operations result in an overflow for the specified type, an
@@ -257,8 +552,7 @@ func (cr *CommandResult) Reading(devName string, doName string) *models.Reading
 	if cr.Origin > 0 {
 		reading.Origin = cr.Origin
 	} else {
-		t := time.Now()
-		reading.Origin = t.Unix()
+		reading.Origin = now()
 	}
 
 	return reading
@@ -277,86 +571,78 @@ func (cr *CommandResult) toString() (str string) {
 		return
 	}
 
-	if cr.Type == String {
+	if cr.Type == String || cr.Type == JSON {
 		str = cr.StringResult
 		return
 	}
 
-	buf := bytes.NewReader(cr.NumericResult)
+	// binary results are encoded as a data URI, which retains
+	// the media type, e.g. data:image/jpeg;base64,...
+	if cr.Type == Binary {
+		str = "data:" + cr.MediaType + ";base64," + base64.StdEncoding.EncodeToString(cr.BinaryResult)
+		return
+	}
 
-	switch cr.Type {
-	case Uint8:
-		var res uint8
-		err := binary.Read(buf, binary.BigEndian, &res)
+	// arrays are encoded as a JSON array
+	if _, ok := arrayElementType(cr.Type); ok {
+		v, err := cr.ArrayValue()
 		if err != nil {
 			str = err.Error()
+			return
 		}
 
-		str = fmt.Sprintf("%d", res)
-	case Uint16:
-		var res uint16
-		err := binary.Read(buf, binary.BigEndian, &res)
-		if err != nil {
-			str = err.Error()
+		// json encodes []uint8 as base64, rather than an array
+		if u8, ok := v.([]uint8); ok {
+			u16 := make([]uint16, len(u8))
+			for i := range u8 {
+				u16[i] = uint16(u8[i])
+			}
+			v = u16
 		}
 
-		str = fmt.Sprintf("%d", res)
-	case Uint32:
-		var res uint32
-		err := binary.Read(buf, binary.BigEndian, &res)
+		b, err := json.Marshal(v)
 		if err != nil {
 			str = err.Error()
+			return
 		}
 
-		str = fmt.Sprintf("%d", res)
-	case Uint64:
-		var res uint64
-		err := binary.Read(buf, binary.BigEndian, &res)
-		if err != nil {
-			str = err.Error()
-		}
+		str = string(b)
+		return
+	}
 
-		str = fmt.Sprintf("%d", res)
-	case Int8:
-		var res int8
-		err := binary.Read(buf, binary.BigEndian, &res)
-		if err != nil {
-			str = err.Error()
-		}
+	var v interface{}
+	var err error
 
-		str = fmt.Sprintf("%d", res)
+	switch cr.Type {
+	case Uint8:
+		v, err = cr.Uint8Value()
+	case Uint16:
+		v, err = cr.Uint16Value()
+	case Uint32:
+		v, err = cr.Uint32Value()
+	case Uint64:
+		v, err = cr.Uint64Value()
+	case Int8:
+		v, err = cr.Int8Value()
 	case Int16:
-		var res int16
-		err := binary.Read(buf, binary.BigEndian, &res)
-		if err != nil {
-			str = err.Error()
-		}
-
-		str = fmt.Sprintf("%d", res)
+		v, err = cr.Int16Value()
 	case Int32:
-		var res int32
-		err := binary.Read(buf, binary.BigEndian, &res)
-		if err != nil {
-			str = err.Error()
-		}
-
-		str = fmt.Sprintf("%d", res)
+		v, err = cr.Int32Value()
 	case Int64:
-		var res int64
-		err := binary.Read(buf, binary.BigEndian, &res)
+		v, err = cr.Int64Value()
+	}
+
+	if v != nil || err != nil {
 		if err != nil {
 			str = err.Error()
+		} else {
+			str = fmt.Sprintf("%d", v)
 		}
+		return
+	}
 
-		str = fmt.Sprintf("%d", res)
-
-	// TODO: implement base64 encoding of float results
-	case Float32:
-		var res float32
-		str = fmt.Sprintf("%f", binary.Read(buf, binary.BigEndian, &res))
-	case Float64:
-		var res float64
-		str = fmt.Sprintf("%f", binary.Read(buf, binary.BigEndian, &res))
+	if f, ok := cr.floatString(floatEncoding{format: FloatEncodingDecimal, precision: -1}); ok {
+		str = f
 	}
 
 	return
@@ -369,36 +655,7 @@ func (cr *CommandResult) String() (str string) {
 	vdrStr := fmt.Sprintf("%v\n", cr.VDR)
 	originStr := fmt.Sprintf("%d\n", cr.Origin)
 
-	var typeStr string
-
-	switch cr.Type {
-	case Bool:
-		typeStr = "Bool: "
-	case String:
-		typeStr = "String: "
-	case Uint8:
-		typeStr = "Uint8: "
-	case Uint16:
-		typeStr = "Uint16: "
-	case Uint32:
-		typeStr = "Uint32: "
-	case Uint64:
-		typeStr = "Uint64: "
-	case Int8:
-		typeStr = "Int8: "
-	case Int16:
-		typeStr = "Int16: "
-	case Int32:
-		typeStr = "Int32: "
-	case Int64:
-		typeStr = "Int64: "
-	case Float32:
-		typeStr = "Float32: "
-	case Float64:
-		typeStr = "Float64: "
-	}
-
-	resultStr := typeStr + cr.toString()
+	resultStr := cr.Type.String() + ": " + cr.toString()
 
 	str = roStr + vdrStr + originStr + resultStr
 
@@ -406,9 +663,27 @@ func (cr *CommandResult) String() (str string) {
 }
 
 // TransformResult applies transforms specified in the given
-// PropertyValue instance.
-func (cr *CommandResult) TransformResult(models.PropertyValue) bool {
+// PropertyValue instance. Transforms of array results apply to
+// each element. Binary and JSON results can't be transformed, so
+// false is returned if the PropertyValue specifies a transform.
+// False is also returned if a transform is invalid for the type
+// of the result, if the transformed value overflows the type, or
+// if the result doesn't match the PropertyValue's assertion.
+func (cr *CommandResult) TransformResult(pv models.PropertyValue) bool {
+	if cr.Type == Binary || cr.Type == JSON {
+		return !hasTransform(pv)
+	}
+
+	if err := cr.transform(pv); err != nil {
+		return false
+	}
+
+	return pv.Assertion == "" || cr.toString() == pv.Assertion
+}
 
-	// TODO: implement base, scale, offset & assertions
-	return true
+// hasTransform returns whether the given PropertyValue specifies
+// any transform of values.
+func hasTransform(pv models.PropertyValue) bool {
+	return pv.Base != "" || pv.Scale != "" || pv.Offset != "" ||
+		pv.Mask != "" || pv.Shift != "" || pv.Assertion != ""
 }
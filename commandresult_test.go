@@ -9,8 +9,11 @@ package device
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"testing"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
 )
 
 // Test NewBoolResult function.
@@ -336,3 +339,166 @@ func TestNewInt64Result(t *testing.T) {
 		t.Errorf("NewInt64Result: cr.Int64Result: %d doesn't match result: %d (#2)", result, res)
 	}
 }
+
+// Test NewBinaryResult function.
+func TestNewBinaryResult(t *testing.T) {
+	cr := NewBinaryResult(nil, nil, 0, []byte{0xff, 0xd8, 0xff}, "image/jpeg")
+	if cr.Type != Binary {
+		t.Errorf("NewBinaryResult: invalid Type: %v", cr.Type)
+	}
+
+	reading := cr.Reading("FakeDevice", "FakeDeviceObject")
+	if reading.Value != "data:image/jpeg;base64,/9j/" {
+		t.Errorf("NewBinaryResult: invalid reading value: %s", reading.Value)
+	}
+
+	if cr.TransformResult(models.PropertyValue{Scale: "2"}) {
+		t.Errorf("NewBinaryResult: scale transform applied to binary result")
+	}
+}
+
+// Test the NewXxxArrayResult functions.
+func TestNewArrayResults(t *testing.T) {
+	var tests = []struct {
+		name     string
+		cr       *CommandResult
+		resType  ResultType
+		expected string
+	}{
+		{"uint8", NewUint8ArrayResult(nil, nil, 0, []uint8{1, 255}), Uint8Array, "[1,255]"},
+		{"uint16", NewUint16ArrayResult(nil, nil, 0, []uint16{1, 65535}), Uint16Array, "[1,65535]"},
+		{"uint32", NewUint32ArrayResult(nil, nil, 0, []uint32{1, 2, 3}), Uint32Array, "[1,2,3]"},
+		{"uint64", NewUint64ArrayResult(nil, nil, 0, []uint64{18446744073709551615}), Uint64Array, "[18446744073709551615]"},
+		{"int8", NewInt8ArrayResult(nil, nil, 0, []int8{-128, 127}), Int8Array, "[-128,127]"},
+		{"int16", NewInt16ArrayResult(nil, nil, 0, []int16{-1, 1}), Int16Array, "[-1,1]"},
+		{"int32", NewInt32ArrayResult(nil, nil, 0, []int32{-42}), Int32Array, "[-42]"},
+		{"int64", NewInt64ArrayResult(nil, nil, 0, []int64{}), Int64Array, "[]"},
+		{"float32", NewFloat32ArrayResult(nil, nil, 0, []float32{0.5, -1.25}), Float32Array, "[0.5,-1.25]"},
+		{"float64", NewFloat64ArrayResult(nil, nil, 0, []float64{9.81, 0, -9.81}), Float64Array, "[9.81,0,-9.81]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.cr.Type != tt.resType {
+				t.Errorf("New%sArrayResult: invalid Type: %v", tt.name, tt.cr.Type)
+			}
+
+			reading := tt.cr.Reading("FakeDevice", "FakeDeviceObject")
+			if reading.Value != tt.expected {
+				t.Errorf("New%sArrayResult: expected reading value: %s, got: %s", tt.name, tt.expected, reading.Value)
+			}
+		})
+	}
+}
+
+// Test NewJSONResult function.
+func TestNewJSONResult(t *testing.T) {
+	value := map[string]interface{}{"x": 1, "y": -2, "z": 9.81}
+
+	cr, err := NewJSONResult(nil, nil, 0, value)
+	if err != nil {
+		t.Fatalf("NewJSONResult: failed: %v", err)
+	}
+
+	if cr.Type != JSON {
+		t.Errorf("NewJSONResult: invalid Type: %v", cr.Type)
+	}
+
+	reading := cr.Reading("FakeDevice", "FakeDeviceObject")
+	if reading.Value != `{"x":1,"y":-2,"z":9.81}` {
+		t.Errorf("NewJSONResult: invalid reading value: %s", reading.Value)
+	}
+
+	if !cr.TransformResult(models.PropertyValue{}) {
+		t.Errorf("NewJSONResult: transform without attributes failed")
+	}
+
+	_, err = NewJSONResult(nil, nil, 0, func() {})
+	if err == nil {
+		t.Errorf("NewJSONResult: expected error for unencodable value")
+	}
+}
+
+// Test NewCommandResult type inference.
+func TestNewCommandResult(t *testing.T) {
+	var tests = []struct {
+		name     string
+		value    interface{}
+		resType  ResultType
+		expected string
+	}{
+		{"Bool", true, Bool, "true"},
+		{"String", "hello", String, "hello"},
+		{"Uint8", uint8(255), Uint8, "255"},
+		{"Uint64", uint64(18446744073709551615), Uint64, "18446744073709551615"},
+		{"Int16", int16(-32768), Int16, "-32768"},
+		{"Binary", []byte{0x01}, Binary, "data:;base64,AQ=="},
+		{"Int32Array", []int32{-1, 2}, Int32Array, "[-1,2]"},
+		{"JSON", json.RawMessage(`{"a":1}`), JSON, `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr, err := NewCommandResult(nil, nil, 0, tt.value)
+			if err != nil {
+				t.Fatalf("NewCommandResult: failed: %v", err)
+			}
+
+			if cr.Type != tt.resType {
+				t.Errorf("NewCommandResult: expected Type: %v, got: %v", tt.resType, cr.Type)
+			}
+
+			reading := cr.Reading("FakeDevice", "FakeDeviceObject")
+			if reading.Value != tt.expected {
+				t.Errorf("NewCommandResult: expected reading value: %s, got: %s", tt.expected, reading.Value)
+			}
+		})
+	}
+
+	for _, v := range []interface{}{int(1), []string{"a"}, json.RawMessage("{"), nil} {
+		_, err := NewCommandResult(nil, nil, 0, v)
+		if err == nil {
+			t.Errorf("NewCommandResult: expected error for value: %#v", v)
+		}
+	}
+}
+
+// Test typed value accessors.
+func TestCommandResultValues(t *testing.T) {
+	i32, err := NewInt32Result(nil, nil, 0, -42).Int32Value()
+	if err != nil || i32 != -42 {
+		t.Errorf("Int32Value: expected -42, got: %d, %v", i32, err)
+	}
+
+	f64, err := NewFloat64Result(nil, nil, 0, 1.5).Float64Value()
+	if err != nil || f64 != 1.5 {
+		t.Errorf("Float64Value: expected 1.5, got: %f, %v", f64, err)
+	}
+
+	b, err := NewBoolResult(nil, nil, 0, true).BoolValue()
+	if err != nil || !b {
+		t.Errorf("BoolValue: expected true, got: %v, %v", b, err)
+	}
+
+	_, err = NewInt16Result(nil, nil, 0, 1).Int32Value()
+	if err == nil {
+		t.Errorf("Int32Value: expected error for Int16 result")
+	}
+
+	_, err = NewStringResult(nil, nil, 0, "1.5").Float64Value()
+	if err == nil {
+		t.Errorf("Float64Value: expected error for String result")
+	}
+
+	a, err := NewUint16ArrayResult(nil, nil, 0, []uint16{1, 2}).ArrayValue()
+	if u16, ok := a.([]uint16); err != nil || !ok || len(u16) != 2 || u16[1] != 2 {
+		t.Errorf("ArrayValue: expected [1 2], got: %v, %v", a, err)
+	}
+
+	var m map[string]int
+	cr, _ := NewJSONResult(nil, nil, 0, map[string]int{"a": 1})
+	err = cr.JSONValue(&m)
+	if err != nil || m["a"] != 1 {
+		t.Errorf("JSONValue: expected map[a:1], got: %v, %v", m, err)
+	}
+}
@@ -0,0 +1,95 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+// commandTimeout returns the deadline for commands sent to the named
+// device; either the device's entry in CommandTimeouts, or Service.Timeout.
+// A zero duration means commands have no deadline.
+func commandTimeout(name string) time.Duration {
+	ms := svc.c.Service.Timeout
+	if t, ok := svc.c.Device.CommandTimeouts[name]; ok {
+		ms = t
+	}
+
+	return time.Duration(ms) * time.Millisecond
+}
+
+// commandContext returns a context derived from parent, with the
+// command deadline for the named device applied.
+func commandContext(parent context.Context, name string) (context.Context, context.CancelFunc) {
+	timeout := commandTimeout(name)
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithTimeout(parent, timeout)
+}
+
+type handleCommandsResult struct {
+	results []CommandResult
+	err     error
+}
+
+// handleCommands calls the ProtocolDriver's HandleCommands, returning
+// ctx.Err() if the context is cancelled or its deadline expires before
+// the driver returns. In that case the driver's results are discarded
+// when it eventually returns, and the device operation carried by ctx,
+// if any, is held until then, so the device isn't removed or used by
+// a serialized command while the driver is still running.
+func handleCommands(ctx context.Context, d models.Device, reqs []CommandRequest, params string) ([]CommandResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// buffered, so the driver goroutine can always complete
+	ch := make(chan handleCommandsResult, 1)
+	proto := svc.proto
+
+	op := operationOf(ctx)
+	if op != nil {
+		op.hold()
+	}
+
+	go func() {
+		if op != nil {
+			defer op.end()
+		}
+
+		results, err := proto.HandleCommands(ctx, d, reqs, params)
+		ch <- handleCommandsResult{results, err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.results, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// commandErrorStatus returns the HTTP status code for an error returned
+// by handleCommands; either the code of a CommandError returned by the
+// driver, or 504 if the command deadline expired.
+func commandErrorStatus(err error) int {
+	if ce, ok := err.(CommandError); ok {
+		return ce.Code
+	}
+
+	if err == context.DeadlineExceeded {
+		return http.StatusGatewayTimeout // status=504
+	}
+
+	return http.StatusInternalServerError // status=500
+}
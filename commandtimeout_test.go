@@ -0,0 +1,142 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+// blockingDriver is a ProtocolDriver whose HandleCommands blocks until
+// it's released, regardless of the context.
+type blockingDriver struct {
+	release chan struct{}
+}
+
+func (b *blockingDriver) DisconnectDevice(address *models.Addressable) error {
+	return nil
+}
+
+func (b *blockingDriver) Initialize(s *Service, lc logger.LoggingClient, asyncCh <-chan *CommandResult) error {
+	return nil
+}
+
+func (b *blockingDriver) HandleCommands(ctx context.Context, d models.Device, reqs []CommandRequest, params string) ([]CommandResult, error) {
+	<-b.release
+	return []CommandResult{}, nil
+}
+
+func (b *blockingDriver) Stop(force bool) error {
+	return nil
+}
+
+func TestCommandTimeout(t *testing.T) {
+	config := Config{}
+	config.Service.Timeout = 5000
+	config.Device.CommandTimeouts = map[string]int{"slow": 10000}
+	svc = &Service{c: &config}
+
+	if timeout := commandTimeout("dev"); timeout != 5*time.Second {
+		t.Errorf("commandTimeout: expected Service.Timeout, got: %v", timeout)
+	}
+
+	if timeout := commandTimeout("slow"); timeout != 10*time.Second {
+		t.Errorf("commandTimeout: expected device override, got: %v", timeout)
+	}
+}
+
+func TestHandleCommandsDeadline(t *testing.T) {
+	driver := &blockingDriver{release: make(chan struct{})}
+	defer close(driver.release)
+
+	config := Config{}
+	config.Service.Timeout = 10
+	svc = &Service{c: &config, proto: driver}
+
+	ctx, cancel := commandContext(context.Background(), "dev")
+	defer cancel()
+
+	_, err := handleCommands(ctx, models.Device{Name: "dev"}, nil, "")
+	if err != context.DeadlineExceeded {
+		t.Fatalf("handleCommands: expected deadline exceeded, got: %v", err)
+	}
+
+	if status := commandErrorStatus(err); status != http.StatusGatewayTimeout {
+		t.Errorf("commandErrorStatus: expected 504, got: %d", status)
+	}
+
+	// a client disconnect cancels the command
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	_, err = handleCommands(ctx, models.Device{Name: "dev"}, nil, "")
+	if err != context.Canceled {
+		t.Errorf("handleCommands: expected cancellation, got: %v", err)
+	}
+}
+
+func TestHandleCommandsHoldsOperation(t *testing.T) {
+	driver := &blockingDriver{release: make(chan struct{})}
+
+	config := Config{}
+	config.Service.Timeout = 10
+	config.Device.SerializeCommands = true
+	svc = &Service{c: &config, proto: driver}
+	ot = newOperationTracker()
+
+	op, err := ot.begin(context.Background(), "dev")
+	if err != nil {
+		t.Fatalf("begin: unexpected error: %v", err)
+	}
+
+	ctx, cancel := commandContext(withOperation(context.Background(), op), "dev")
+	defer cancel()
+
+	_, err = handleCommands(ctx, models.Device{Name: "dev"}, nil, "")
+	if err != context.DeadlineExceeded {
+		t.Fatalf("handleCommands: expected deadline exceeded, got: %v", err)
+	}
+	op.end()
+
+	// the driver is still running, so the device remains in use, and a
+	// serialized command waiting for it times out rather than hanging
+	waitCtx, waitCancel := commandContext(context.Background(), "dev")
+	defer waitCancel()
+
+	if _, err := ot.begin(waitCtx, "dev"); err != context.DeadlineExceeded {
+		t.Fatalf("begin: expected deadline exceeded while driver running, got: %v", err)
+	}
+
+	if status := commandErrorStatus(waitCtx.Err()); status != http.StatusGatewayTimeout {
+		t.Errorf("commandErrorStatus: expected %d for waiting command, got: %d", http.StatusGatewayTimeout, status)
+	}
+
+	if err := ot.beginRemove("dev", 10*time.Millisecond); err != errDeviceBusy {
+		t.Errorf("beginRemove: expected errDeviceBusy, got: %v", err)
+	}
+
+	close(driver.release)
+
+	waitCtx, waitCancel = context.WithTimeout(context.Background(), time.Second)
+	defer waitCancel()
+
+	op, err = ot.begin(waitCtx, "dev")
+	if err != nil {
+		t.Fatalf("begin: serialized command not started after driver returned: %v", err)
+	}
+	op.end()
+
+	if err := ot.beginRemove("dev", time.Second); err != nil {
+		t.Errorf("beginRemove: unexpected error after driver returned: %v", err)
+	}
+	ot.endRemove("dev")
+}
@@ -37,10 +37,12 @@ type ServiceInfo struct {
 	// OpenMsg specifies a string logged on DS startup.
 	OpenMsg string
 	// ReadMaxLimit specifies the maximum size list supported
-	// in response to REST calls to other services.
+	// in response to REST calls to other services. Events with
+	// more readings are split before being pushed to Core Data.
 	ReadMaxLimit int
 	// Timeout specifies a timeout (in milliseconds) for
-	// processing REST calls from other services.
+	// processing REST calls from other services. It's also
+	// the default deadline for commands sent to a ProtocolDriver.
 	Timeout int
 }
 
@@ -79,16 +81,71 @@ type DeviceInfo struct {
 	MaxCmdOps int
 	// MaxCmdValueLen is the maximum string length of a command parameter or
 	// result (including the valuedescriptor name) that can be returned
-	// by a ProtocolDriver.
+	// by a ProtocolDriver. PUT request bodies are limited to MaxCmdOps
+	// parameters of this length.
 	MaxCmdValueLen int
+	// MaxCmdValueLenPolicy specifies how String results which exceed
+	// MaxCmdValueLen are handled; either "reject" (the default), which
+	// fails the command, or "truncate". Other results are always rejected.
+	MaxCmdValueLenPolicy string
+	// MaxCmdBinaryValueLen, if non-zero, overrides MaxCmdValueLen for
+	// Binary, JSON and array results, e.g. camera images. Such results
+	// are never truncated; they're rejected if they exceed the limit.
+	MaxCmdBinaryValueLen int
+	// CommandTimeouts overrides Service.Timeout for commands sent
+	// to specific devices; values are in milliseconds, keyed by
+	// device name.
+	CommandTimeouts map[string]int
+	// SerializeCommands causes commands for the same device to be handled
+	// one at a time, e.g. for devices on a half-duplex bus.
+	SerializeCommands bool
+	// RemoveWaitTimeout specifies how long (in milliseconds) removal of a
+	// device waits for commands in progress to complete. If zero, a device
+	// can't be removed while commands are in progress.
+	RemoveWaitTimeout int
 	// InitCmd specifies a device resource command which is automatically
 	// generated whenever a new device is removed from the DS.
 	RemoveCmd string
 	// RemoveCmdArgs specify arguments to be used when building the RemoveCmd.
 	RemoveCmdArgs string
 	// ProfilesDir specifies a directory which contains deviceprofile
-	// files which should be imported on startup.
+	// files which should be imported on startup. Files may be YAML
+	// (.yaml or .yml) or JSON (.json), and may contain several profiles.
 	ProfilesDir string
+	// ProfilesWatchInterval specifies how often (in seconds) the ProfilesDir
+	// is checked for new, changed or deleted deviceprofile files. If zero,
+	// the ProfilesDir is only read on startup.
+	ProfilesWatchInterval int
+	// ProfilesRemoveDeleted specifies whether a deviceprofile is removed
+	// from Core Metadata when its file is deleted from the ProfilesDir. By
+	// default, deleted files are only logged.
+	ProfilesRemoveDeleted bool
+	// EventBufferSize specifies the number of recent events held in memory,
+	// which can be queried through the events endpoint. If zero, recent
+	// events aren't held.
+	EventBufferSize int
+	// EventBufferSpillFile specifies a file to which events are appended
+	// when they're evicted from the event buffer. If empty, evicted
+	// events are discarded.
+	EventBufferSpillFile string
+	// EventBufferSpillMaxSize specifies the maximum size (in bytes) of the
+	// EventBufferSpillFile, after which it's rotated. A single rotated file
+	// is kept, with ".1" appended to its name.
+	EventBufferSpillMaxSize int
+	// TimestampPrecision specifies the unit of reading and event origins;
+	// either "ms" (milliseconds, the default) or "ns" (nanoseconds).
+	TimestampPrecision string
+	// MaxClockDrift specifies the tolerance (in milliseconds) for the
+	// difference between the origin of a reading set by a ProtocolDriver
+	// and the service clock. Devices whose timestamps drift further are
+	// logged and flagged by the last reading endpoint. If zero, drift
+	// isn't checked.
+	MaxClockDrift int
+	// ResultValidation specifies how results which are out of the range of
+	// their device resource, or of the wrong type, are handled, unless
+	// overridden by the resource's "validation" attribute; one of "reject",
+	// "clamp" or "mark". If empty, results aren't validated.
+	ResultValidation string
 	// SendReaingsOnChanged can be used to cause a DS to only send readings
 	// to Core Data when the reading has changed (based on comparison to an
 	// existing reading in the cache, if present).
@@ -0,0 +1,234 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+const (
+	// ProtocolTCP is the Addressable protocol of TCP connections. Address
+	// is the host name or IP address, and Port is required.
+	ProtocolTCP = "TCP"
+	// ProtocolSerial is the Addressable protocol of serial connections.
+	// Address is the device path, e.g. /dev/ttyUSB0, Port is the baud
+	// rate (9600 by default), and Path is the frame format as data bits,
+	// parity and stop bits (8N1 by default).
+	ProtocolSerial = "SERIAL"
+	// ProtocolHTTP is the Addressable protocol of HTTP endpoints. Address
+	// is the host name or IP address, Port is optional, Path is the URL
+	// path and HTTPMethod the method (GET by default).
+	ProtocolHTTP = "HTTP"
+	// ProtocolHTTPS is the Addressable protocol of HTTPS endpoints, which
+	// are described as per ProtocolHTTP.
+	ProtocolHTTPS = "HTTPS"
+
+	// ParityNone, ParityEven and ParityOdd are the parities of serial
+	// connections.
+	ParityNone = "N"
+	ParityEven = "E"
+	ParityOdd  = "O"
+)
+
+// ConnectionSpec is the typed connection details of a device, parsed from
+// its Addressable. The built-in specs are *TCPSpec, *SerialSpec and
+// *HTTPSpec; a ProtocolDriver may register parsers for other protocols
+// using RegisterConnectionParser.
+type ConnectionSpec interface {
+	// Protocol returns the Addressable protocol of the connection.
+	Protocol() string
+	// String returns a description of the connection, without any
+	// credentials, for logging.
+	String() string
+}
+
+// TCPSpec is the ConnectionSpec of a TCP connection.
+type TCPSpec struct {
+	Host string
+	Port int
+}
+
+// Protocol returns ProtocolTCP.
+func (s *TCPSpec) Protocol() string {
+	return ProtocolTCP
+}
+
+// Address returns the host and port in the form used by net.Dial.
+func (s *TCPSpec) Address() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+func (s *TCPSpec) String() string {
+	return "tcp://" + s.Address()
+}
+
+// SerialSpec is the ConnectionSpec of a serial connection.
+type SerialSpec struct {
+	Device   string
+	BaudRate int
+	DataBits int
+	Parity   string
+	StopBits int
+}
+
+// Protocol returns ProtocolSerial.
+func (s *SerialSpec) Protocol() string {
+	return ProtocolSerial
+}
+
+func (s *SerialSpec) String() string {
+	return fmt.Sprintf("%s %d %d%s%d", s.Device, s.BaudRate, s.DataBits, s.Parity, s.StopBits)
+}
+
+// HTTPSpec is the ConnectionSpec of an HTTP or HTTPS endpoint. URL holds
+// no credentials; they're in User and Password.
+type HTTPSpec struct {
+	URL      *url.URL
+	Method   string
+	User     string
+	Password string
+}
+
+// Protocol returns ProtocolHTTP or ProtocolHTTPS, as per the URL scheme.
+func (s *HTTPSpec) Protocol() string {
+	return strings.ToUpper(s.URL.Scheme)
+}
+
+func (s *HTTPSpec) String() string {
+	return s.Method + " " + s.URL.String()
+}
+
+// ConnectionParser parses an Addressable into a ConnectionSpec.
+type ConnectionParser func(addr *models.Addressable) (ConnectionSpec, error)
+
+var connectionParsersMutex sync.RWMutex
+
+// connectionParsers is keyed by upper case Addressable protocol.
+var connectionParsers = map[string]ConnectionParser{
+	ProtocolTCP:    parseTCP,
+	ProtocolSerial: parseSerial,
+	ProtocolHTTP:   parseHTTP,
+	ProtocolHTTPS:  parseHTTP,
+}
+
+// RegisterConnectionParser adds a parser for the given Addressable
+// protocol, or replaces an existing one. Protocols are matched without
+// regard to case. Parsers must be registered before the Service is
+// started, so that devices are validated when they're added.
+func RegisterConnectionParser(protocol string, parser ConnectionParser) error {
+	if protocol == "" || parser == nil {
+		return fmt.Errorf("connection parser protocol and parser must be set")
+	}
+
+	connectionParsersMutex.Lock()
+	connectionParsers[strings.ToUpper(protocol)] = parser
+	connectionParsersMutex.Unlock()
+
+	return nil
+}
+
+// ParseAddressable returns the ConnectionSpec of the given Addressable.
+// False is returned if there's no parser for its protocol, and an error
+// if it doesn't describe a valid connection.
+func ParseAddressable(addr *models.Addressable) (ConnectionSpec, bool, error) {
+	connectionParsersMutex.RLock()
+	parse, ok := connectionParsers[strings.ToUpper(addr.Protocol)]
+	connectionParsersMutex.RUnlock()
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	spec, err := parse(addr)
+	if err != nil {
+		return nil, false, fmt.Errorf("addressable: %s %v", addr.Name, err)
+	}
+
+	return spec, true, nil
+}
+
+func parseTCP(addr *models.Addressable) (ConnectionSpec, error) {
+	if addr.Address == "" {
+		return nil, fmt.Errorf("has no address")
+	}
+
+	if addr.Port <= 0 || addr.Port > 65535 {
+		return nil, fmt.Errorf("has invalid port: %d", addr.Port)
+	}
+
+	return &TCPSpec{Host: addr.Address, Port: addr.Port}, nil
+}
+
+var serialFrameRe = regexp.MustCompile(`^([5-8])([NEO])([12])$`)
+
+func parseSerial(addr *models.Addressable) (ConnectionSpec, error) {
+	if addr.Address == "" {
+		return nil, fmt.Errorf("has no device path")
+	}
+
+	spec := &SerialSpec{Device: addr.Address, BaudRate: 9600, DataBits: 8, Parity: ParityNone, StopBits: 1}
+
+	if addr.Port < 0 {
+		return nil, fmt.Errorf("has invalid baud rate: %d", addr.Port)
+	} else if addr.Port > 0 {
+		spec.BaudRate = addr.Port
+	}
+
+	if frame := strings.Trim(addr.Path, "/"); frame != "" {
+		m := serialFrameRe.FindStringSubmatch(strings.ToUpper(frame))
+		if m == nil {
+			return nil, fmt.Errorf("has invalid serial frame format: %s; expected e.g. 8N1", addr.Path)
+		}
+
+		spec.DataBits, _ = strconv.Atoi(m[1])
+		spec.Parity = m[2]
+		spec.StopBits, _ = strconv.Atoi(m[3])
+	}
+
+	return spec, nil
+}
+
+func parseHTTP(addr *models.Addressable) (ConnectionSpec, error) {
+	scheme := strings.ToLower(addr.Protocol)
+
+	// tolerate an address given with its scheme
+	host := strings.TrimPrefix(addr.Address, scheme+"://")
+	if host == "" || strings.ContainsAny(host, "/?#@") {
+		return nil, fmt.Errorf("has invalid address: %s", addr.Address)
+	}
+
+	if addr.Port < 0 || addr.Port > 65535 {
+		return nil, fmt.Errorf("has invalid port: %d", addr.Port)
+	} else if addr.Port > 0 {
+		host = net.JoinHostPort(host, strconv.Itoa(addr.Port))
+	}
+
+	path := addr.Path
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	u, err := url.Parse(scheme + "://" + host + path)
+	if err != nil {
+		return nil, fmt.Errorf("has invalid URL: %v", err)
+	}
+
+	method := strings.ToUpper(addr.HTTPMethod)
+	if method == "" {
+		method = "GET"
+	}
+
+	return &HTTPSpec{URL: u, Method: method, User: addr.User, Password: addr.Password}, nil
+}
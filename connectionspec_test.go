@@ -0,0 +1,176 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/edgexfoundry/device-sdk-go/mock"
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+func TestParseAddressable(t *testing.T) {
+	var tests = []struct {
+		name     string
+		addr     models.Addressable
+		expected string
+		valid    bool
+	}{
+		{"TCP", models.Addressable{Protocol: "TCP", Address: "10.0.0.5", Port: 502}, "tcp://10.0.0.5:502", true},
+		{"TCP IPv6", models.Addressable{Protocol: "tcp", Address: "fe80::1", Port: 502}, "tcp://[fe80::1]:502", true},
+		{"TCP no port", models.Addressable{Protocol: "TCP", Address: "10.0.0.5"}, "", false},
+		{"TCP no address", models.Addressable{Protocol: "TCP", Port: 502}, "", false},
+		{"Serial defaults", models.Addressable{Protocol: "SERIAL", Address: "/dev/ttyUSB0"}, "/dev/ttyUSB0 9600 8N1", true},
+		{"Serial", models.Addressable{Protocol: "Serial", Address: "/dev/ttyS1", Port: 19200, Path: "7e2"}, "/dev/ttyS1 19200 7E2", true},
+		{"Serial bad frame", models.Addressable{Protocol: "SERIAL", Address: "/dev/ttyS1", Path: "9X1"}, "", false},
+		{"Serial bad baud", models.Addressable{Protocol: "SERIAL", Address: "/dev/ttyS1", Port: -1}, "", false},
+		{"HTTP", models.Addressable{Protocol: "HTTP", Address: "camera", Port: 8080, Path: "api/v1/snapshot", HTTPMethod: "post"}, "POST http://camera:8080/api/v1/snapshot", true},
+		{"HTTPS with scheme", models.Addressable{Protocol: "HTTPS", Address: "https://camera", User: "admin", Password: "secret"}, "GET https://camera", true},
+		{"HTTP bad address", models.Addressable{Protocol: "HTTP", Address: "camera/api"}, "", false},
+		{"HTTP bad port", models.Addressable{Protocol: "HTTP", Address: "camera", Port: 70000}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, ok, err := ParseAddressable(&tt.addr)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("ParseAddressable: expected error, got: %v", spec)
+				}
+				return
+			}
+
+			if err != nil || !ok {
+				t.Fatalf("ParseAddressable: unexpected error: %v", err)
+			}
+
+			if spec.String() != tt.expected {
+				t.Errorf("ParseAddressable: expected: %s, got: %s", tt.expected, spec)
+			}
+		})
+	}
+
+	spec, ok, err := ParseAddressable(&models.Addressable{Protocol: "HTTPS", Address: "camera", User: "admin", Password: "secret"})
+	http, isHTTP := spec.(*HTTPSpec)
+	if err != nil || !ok || !isHTTP || http.Protocol() != ProtocolHTTPS || http.User != "admin" || http.Password != "secret" {
+		t.Errorf("ParseAddressable: expected HTTPS spec with credentials, got: %#v, %v", spec, err)
+	}
+
+	if spec, ok, err := ParseAddressable(&models.Addressable{Protocol: "ZMQ", Address: "anything"}); spec != nil || ok || err != nil {
+		t.Errorf("ParseAddressable: expected no spec for unknown protocol, got: %v, %v", spec, err)
+	}
+}
+
+type customSpec struct {
+	topic string
+}
+
+func (s *customSpec) Protocol() string {
+	return "MQTT"
+}
+
+func (s *customSpec) String() string {
+	return "mqtt " + s.topic
+}
+
+func TestRegisterConnectionParser(t *testing.T) {
+	defer delete(connectionParsers, "MQTT")
+
+	err := RegisterConnectionParser("mqtt", func(addr *models.Addressable) (ConnectionSpec, error) {
+		if addr.Topic == "" {
+			return nil, fmt.Errorf("has no topic")
+		}
+		return &customSpec{addr.Topic}, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterConnectionParser: unexpected error: %v", err)
+	}
+
+	spec, ok, err := ParseAddressable(&models.Addressable{Protocol: "MQTT", Topic: "meters"})
+	if err != nil || !ok || !reflect.DeepEqual(spec, &customSpec{"meters"}) {
+		t.Errorf("ParseAddressable: expected custom spec, got: %v, %v", spec, err)
+	}
+
+	if _, _, err := ParseAddressable(&models.Addressable{Name: "broker", Protocol: "MQTT"}); err == nil || err.Error() != "addressable: broker has no topic" {
+		t.Errorf("ParseAddressable: expected custom parser error, got: %v", err)
+	}
+
+	if err := RegisterConnectionParser("", nil); err == nil {
+		t.Errorf("RegisterConnectionParser: expected error")
+	}
+}
+
+// lifecycleDriver is a ProtocolDriver which records its DeviceLifecycle calls.
+type lifecycleDriver struct {
+	blockingDriver
+	calls []string
+}
+
+func (l *lifecycleDriver) DeviceAdded(dev *models.Device, spec ConnectionSpec) error {
+	l.calls = append(l.calls, fmt.Sprintf("added %s %v", dev.Name, spec))
+	return nil
+}
+
+func (l *lifecycleDriver) DeviceUpdated(dev *models.Device, spec ConnectionSpec) error {
+	l.calls = append(l.calls, fmt.Sprintf("updated %s %v", dev.Name, spec))
+	return nil
+}
+
+func (l *lifecycleDriver) DeviceRemoved(dev *models.Device) error {
+	l.calls = append(l.calls, "removed "+dev.Name)
+	return fmt.Errorf("still connected")
+}
+
+func TestDeviceLifecycle(t *testing.T) {
+	driver := &lifecycleDriver{}
+	svc = &Service{c: &Config{}, lc: logger.NewClient("connectionspec_test", false, ""), proto: driver}
+	svc.ac = mock.AddressableClientMock{}
+	svc.dc = &mock.DeviceClientMock{}
+	dc = &deviceCache{devices: map[string]*models.Device{}, names: map[string]string{}}
+
+	dev := &models.Device{Name: "meter", Addressable: models.Addressable{Name: "meter", Protocol: "TCP", Address: "10.0.0.5", Port: 502}}
+	if err := dc.Update(dev); err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+
+	bad := &models.Device{Name: "meter", Addressable: models.Addressable{Name: "meter", Protocol: "TCP", Address: "10.0.0.5"}}
+	if err := dc.Update(bad); err == nil {
+		t.Errorf("Update: expected invalid addressable error")
+	}
+
+	notifyDeviceRemoved(dev)
+
+	expected := []string{"updated meter tcp://10.0.0.5:502", "removed meter"}
+	if !reflect.DeepEqual(driver.calls, expected) {
+		t.Errorf("DeviceLifecycle: expected calls: %v, got: %v", expected, driver.calls)
+	}
+}
+
+func TestAddressableSpec(t *testing.T) {
+	svc = &Service{c: &Config{}, lc: logger.NewClient("connectionspec_test", false, "")}
+	dev := &models.Device{Name: "meter"}
+	valid := &models.Addressable{Name: "meter", Protocol: "TCP", Address: "10.0.0.5", Port: 502}
+	invalid := &models.Addressable{Name: "meter", Protocol: "TCP", Address: "10.0.0.5"}
+
+	spec, err := addressableSpec(dev, valid, true)
+	if err != nil || fmt.Sprint(spec) != "tcp://10.0.0.5:502" {
+		t.Errorf("addressableSpec: expected spec for valid addressable, got: %v, %v", spec, err)
+	}
+
+	// existing devices are kept, e.g. after an upgrade
+	spec, err = addressableSpec(dev, invalid, true)
+	if err != nil || spec != nil {
+		t.Errorf("addressableSpec: expected existing device kept with nil spec, got: %v, %v", spec, err)
+	}
+
+	if _, err = addressableSpec(dev, invalid, false); err == nil {
+		t.Errorf("addressableSpec: expected error for new device with invalid addressable")
+	}
+}
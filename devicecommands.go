@@ -0,0 +1,184 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+// DeviceCommands is returned by the device command discovery endpoints,
+// and lists the commands supported by a device.
+type DeviceCommands struct {
+	Id       string        `json:"id"`
+	Name     string        `json:"name"`
+	Profile  string        `json:"profile"`
+	Commands []CommandInfo `json:"commands"`
+}
+
+// CommandInfo describes a single device command.
+type CommandInfo struct {
+	Name string `json:"name"`
+	// Methods lists the HTTP methods supported by the command.
+	Methods []string `json:"methods"`
+	// Readings describes the readings returned by a GET command.
+	Readings []CommandValue `json:"readings,omitempty"`
+	// Parameters describes the parameters accepted by a PUT command.
+	Parameters []CommandValue `json:"parameters,omitempty"`
+}
+
+// CommandValue describes a reading or parameter of a command.
+type CommandValue struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Units        string `json:"units,omitempty"`
+	Minimum      string `json:"minimum,omitempty"`
+	Maximum      string `json:"maximum,omitempty"`
+	DefaultValue string `json:"defaultValue,omitempty"`
+	// Values lists the allowed values, if the value is mapped.
+	Values []string `json:"values,omitempty"`
+}
+
+// deviceCommands returns the commands supported by the given device, as
+// cached in the profileCache; the profile's resources, followed by the
+// commands created for device resources which have no resource. Commands
+// are listed in profile order.
+func deviceCommands(d *models.Device) DeviceCommands {
+	dcs := DeviceCommands{Id: d.Id.Hex(), Name: d.Name, Profile: d.Profile.Name, Commands: []CommandInfo{}}
+
+	methods := pc.commandMethods(d.Name)
+
+	// commands are cached by lower case name
+	var names []string
+	listed := make(map[string]bool)
+	list := func(name string) {
+		key := strings.ToLower(name)
+		if _, ok := methods[key]; ok && !listed[key] {
+			listed[key] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, r := range d.Profile.Resources {
+		list(r.Name)
+	}
+
+	for _, dr := range d.Profile.DeviceResources {
+		list(dr.Name)
+	}
+
+	var others []string
+	for key := range methods {
+		if !listed[key] {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	names = append(names, others...)
+
+	for _, name := range names {
+		info := CommandInfo{Name: name, Methods: methods[strings.ToLower(name)]}
+
+		var err error
+		for _, method := range info.Methods {
+			if method == http.MethodGet {
+				info.Readings, err = commandValues(d.Name, name, method)
+			} else {
+				info.Parameters, err = commandValues(d.Name, name, method)
+			}
+
+			if err != nil {
+				break
+			}
+		}
+
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("dev: %s cmd: %s not listed; %v", d.Name, name, err))
+			continue
+		}
+
+		dcs.Commands = append(dcs.Commands, info)
+	}
+
+	return dcs
+}
+
+// commandValues returns the values read or written by the given command.
+func commandValues(devName string, cmd string, method string) ([]CommandValue, error) {
+	ops, err := pc.GetResourceOperations(devName, cmd, method)
+	if err != nil {
+		return nil, err
+	}
+
+	ops, err = pc.resolveResourceChains(devName, cmd, method, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]CommandValue, 0, len(ops))
+	for i := range ops {
+		op := &ops[i]
+
+		devObj := pc.getDeviceObjectByName(devName, op)
+		if devObj == nil {
+			return nil, fmt.Errorf("no devobject: %s", op.Object)
+		}
+
+		// PUT parameters are keyed by the operation's parameter name
+		name := devObj.Name
+		if method == http.MethodPut && op.Parameter != "" {
+			name = op.Parameter
+		}
+
+		pv, units := publishedProperties(devObj)
+		v := CommandValue{
+			Name:         name,
+			Type:         pv.Type,
+			Units:        units,
+			Minimum:      pv.Minimum,
+			Maximum:      pv.Maximum,
+			DefaultValue: pv.DefaultValue,
+		}
+
+		for _, mapped := range op.Mappings {
+			v.Values = append(v.Values, mapped)
+		}
+		sort.Strings(v.Values)
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+func deviceCommandsFunc(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var d *models.Device
+	var dev string
+	if id, ok := vars["id"]; ok {
+		d, dev = dc.DeviceById(id), id
+	} else {
+		d, dev = dc.Device(vars["name"]), vars["name"]
+	}
+
+	if d == nil {
+		msg := fmt.Sprintf("dev: %s not found; %s %s", dev, r.Method, r.URL)
+		svc.lc.Error(msg)
+		writeError(w, http.StatusNotFound, msg, dev, "") // status=404
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deviceCommands(d))
+}
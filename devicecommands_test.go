@@ -0,0 +1,110 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+	"github.com/gorilla/mux"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestDeviceCommands(t *testing.T) {
+	lc := logger.NewClient("devicecommands_test", false, "")
+	r := mux.NewRouter().PathPrefix(apiV1).Subrouter()
+	svc = &Service{Name: "devicecommands-test", c: &Config{}, lc: lc, r: r}
+	initCommand()
+
+	switchGet := models.ResourceOperation{Operation: "get", Object: "Switch"}
+	switchSet := models.ResourceOperation{Operation: "set", Object: "Switch", Parameter: "State",
+		Mappings: map[string]string{"0": "OFF", "1": "ON"}}
+	tempGet := models.ResourceOperation{Operation: "get", Object: "Temperature"}
+	humidityGet := models.ResourceOperation{Operation: "get", Object: "Humidity", Parameter: "Humidity"}
+
+	dev := &models.Device{Name: "thermostat", Id: bson.NewObjectId()}
+	dev.Profile.Name = "Thermostat"
+	dev.Profile.Resources = []models.ProfileResource{
+		{Name: "Switch", Get: []models.ResourceOperation{switchGet}, Set: []models.ResourceOperation{switchSet}},
+		{Name: "Temperature", Get: []models.ResourceOperation{tempGet}},
+	}
+
+	// device resources without a resource have an implicit command
+	humidity := models.DeviceObject{Name: "Humidity"}
+	humidity.Properties.Value = models.PropertyValue{Type: "Float32", ReadWrite: "R"}
+	dev.Profile.DeviceResources = []models.DeviceObject{{Name: "Switch"}, {Name: "Temperature"}, humidity}
+
+	temp := models.DeviceObject{Name: "Temperature"}
+	temp.Properties.Value = models.PropertyValue{Type: "Float32", Minimum: "-40", Maximum: "85"}
+	temp.Properties.Units = models.Units{DefaultValue: "degC"}
+
+	pc = &profileCache{
+		objects: map[string]map[string]models.DeviceObject{
+			dev.Name: {"Switch": {Name: "Switch"}, "Temperature": temp, "Humidity": humidity},
+		},
+		commands: map[string]map[string]map[string][]models.ResourceOperation{
+			dev.Name: {
+				"switch":      {"get": {switchGet}, "set": {switchSet}},
+				"temperature": {"get": {tempGet}},
+				"humidity":    {"get": {humidityGet}},
+			},
+		},
+	}
+
+	cache := &deviceCache{}
+	cache.InitDeviceCache()
+	cache.devices[dev.Name] = dev
+	cache.names[dev.Id.Hex()] = dev.Name
+	dc = cache
+
+	for _, path := range []string{v1Device + "/" + dev.Id.Hex(), v1Device + "/name/" + dev.Name} {
+		rr := httptest.NewRecorder()
+		svc.r.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("deviceCommandsFunc: %s returned wrong status code: %d", path, rr.Code)
+		}
+
+		var dcs DeviceCommands
+		err := json.NewDecoder(rr.Body).Decode(&dcs)
+		if err != nil {
+			t.Fatalf("deviceCommandsFunc: invalid JSON: %v", err)
+		}
+
+		if dcs.Name != dev.Name || dcs.Profile != "Thermostat" || len(dcs.Commands) != 3 {
+			t.Fatalf("deviceCommandsFunc: wrong commands returned: %v", dcs)
+		}
+
+		sw := dcs.Commands[0]
+		if len(sw.Methods) != 2 || len(sw.Parameters) != 1 || sw.Parameters[0].Name != "State" ||
+			len(sw.Parameters[0].Values) != 2 || sw.Parameters[0].Values[0] != "OFF" {
+			t.Errorf("deviceCommandsFunc: wrong Switch command: %v", sw)
+		}
+
+		tc := dcs.Commands[1]
+		expected := CommandValue{Name: "Temperature", Type: "Float32", Units: "degC", Minimum: "-40", Maximum: "85"}
+		if len(tc.Methods) != 1 || tc.Methods[0] != http.MethodGet || len(tc.Readings) != 1 ||
+			tc.Readings[0].Name != expected.Name || tc.Readings[0].Units != expected.Units ||
+			tc.Readings[0].Minimum != expected.Minimum || tc.Readings[0].Maximum != expected.Maximum {
+			t.Errorf("deviceCommandsFunc: wrong Temperature command: %v", tc)
+		}
+
+		hc := dcs.Commands[2]
+		if hc.Name != "Humidity" || len(hc.Methods) != 1 || len(hc.Readings) != 1 || hc.Readings[0].Type != "Float32" {
+			t.Errorf("deviceCommandsFunc: wrong Humidity command: %v", hc)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	svc.r.ServeHTTP(rr, httptest.NewRequest("GET", v1Device+"/name/missing", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("deviceCommandsFunc: missing device returned: %d", rr.Code)
+	}
+}
@@ -29,16 +29,23 @@ type deviceCacheInterface interface {
 	Add(dev *models.Device) error
 	AddById(id string) error
 	Update(dev *models.Device) error
+	Replace(dev *models.Device)
 	UpdateAdminState(id string) error
 	DeviceById(id string) *models.Device
 	Remove(dev *models.Device) error
+	RemoveById(id string) error
 	IsDeviceLocked(id string) (exists, locked bool)
 	SetDeviceOpState(name string, os models.OperatingState) error
 	SetDeviceByIdOpState(id string, os models.OperatingState) error
 }
 
 // deviceCache is a local cache of devices seeded from Core Metadata.
+// Cached devices are replaced rather than modified, as they may be in use
+// by commands.
 type deviceCache struct {
+	// mutex guards the maps below, which may be updated by the
+	// profileWatcher while commands are being executed.
+	mutex   sync.RWMutex
 	devices map[string]*models.Device
 	names   map[string]string
 }
@@ -88,21 +95,30 @@ func (d *deviceCache) InitDeviceCache() {
 func (d *deviceCache) Add(dev *models.Device) error {
 
 	// if device already exists in devices, delete & re-add
+	d.mutex.Lock()
 	if _, ok := d.devices[dev.Name]; ok {
 		pc.removeDevice(dev)
 		delete(d.names, dev.Id.Hex())
 		delete(d.devices, dev.Name)
 	}
+	d.mutex.Unlock()
 
 	svc.lc.Debug(fmt.Sprintf("Adding managed device: : %v\n", dev))
 
 	// TODO: per effective go, should these two stmts be collapsed?
 	// check if this is commonly used in Go src & snapd.
-	err := d.addDeviceToMetadata(dev)
+	spec, err := d.addDeviceToMetadata(dev)
 	if err != nil {
 		return err
 	}
 
+	if l, ok := deviceLifecycle(); ok {
+		err = l.DeviceAdded(dev, spec)
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("ProtocolDriver.DeviceAdded: %s; failed: %v\n", dev.Name, err))
+		}
+	}
+
 	// This is only the case for brand new devices
 	if dev.OperatingState == models.OperatingState("ENABLED") {
 		svc.lc.Debug(fmt.Sprintf("Initializing device: : %v\n", dev))
@@ -121,11 +137,17 @@ func (d *deviceCache) AddById(id string) error {
 
 // Device returns a device with the given name.
 func (d *deviceCache) Device(name string) *models.Device {
-	return nil
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return d.devices[name]
 }
 
 // DeviceById returns a device with the given device id.
 func (d *deviceCache) DeviceById(id string) *models.Device {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
 	name, ok := d.names[id]
 	if !ok {
 		return nil
@@ -135,13 +157,17 @@ func (d *deviceCache) DeviceById(id string) *models.Device {
 	return dev
 }
 
-// Devices returns the current list of devices in the cache.
-// TODO: based on the java code; we need to check how this function
-// is used, as it's bad form to return an internal data struct to
-// callers, especially when the result is a map, which can then be
-// modified externally to this package.
+// Devices returns a copy of the current list of devices in the cache.
 func (d *deviceCache) Devices() map[string]*models.Device {
-	return d.devices
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	devices := make(map[string]*models.Device, len(d.devices))
+	for name, dev := range d.devices {
+		devices[name] = dev
+	}
+
+	return devices
 }
 
 // IsDeviceLocked returns a bool which indicates if the specified
@@ -150,20 +176,91 @@ func (d *deviceCache) IsDeviceLocked(id string) (exists, locked bool) {
 	return false, false
 }
 
-// Remove removes the specified device from the cache.
+// Remove removes the specified device from the cache. The device is
+// removed once any commands in progress have completed, and an error
+// is returned if they don't complete within RemoveWaitTimeout.
 func (d *deviceCache) Remove(dev *models.Device) error {
-	err := svc.dc.Delete(dev.Id.Hex())
+	err := ot.beginRemove(dev.Name, removeWaitTimeout())
 	if err != nil {
 		return err
 	}
+	defer ot.endRemove(dev.Name)
 
+	// the device may have been removed while waiting for commands
+	if d.cached(dev.Id.Hex(), dev.Name) == nil {
+		return errors.New("Device not found")
+	}
+
+	err = svc.dc.Delete(dev.Id.Hex())
+	if err != nil {
+		return err
+	}
+
+	notifyDeviceRemoved(dev)
 	pc.removeDevice(dev)
+	rc.removeDevice(dev.Name)
+	ag.removeDevice(dev.Name)
+	dt.removeDevice(dev.Name)
+	vs.removeDevice(dev.Name)
+	d.mutex.Lock()
 	delete(d.names, dev.Id.Hex())
 	delete(d.devices, dev.Name)
+	d.mutex.Unlock()
+
+	return nil
+}
+
+// RemoveById removes the device with the specified id from the cache,
+// without deleting it from Core Metadata. This method is used by the
+// UpdateHandler when a device has been deleted directly from Core
+// Metadata. As with Remove, commands in progress are allowed to complete.
+func (d *deviceCache) RemoveById(id string) error {
+	d.mutex.RLock()
+	name, ok := d.names[id]
+	d.mutex.RUnlock()
+	if !ok {
+		return errors.New("Device not found")
+	}
+
+	err := ot.beginRemove(name, removeWaitTimeout())
+	if err != nil {
+		return err
+	}
+	defer ot.endRemove(name)
+
+	// the device may have been removed while waiting for commands
+	dev := d.cached(id, name)
+	if dev == nil {
+		return errors.New("Device not found")
+	}
+
+	notifyDeviceRemoved(dev)
+	pc.removeDevice(dev)
+	rc.removeDevice(name)
+	ag.removeDevice(name)
+	dt.removeDevice(name)
+	vs.removeDevice(name)
+	d.mutex.Lock()
+	delete(d.names, id)
+	delete(d.devices, name)
+	d.mutex.Unlock()
 
 	return nil
 }
 
+// cached returns the cached device with the given id and name, or nil if
+// there isn't one.
+func (d *deviceCache) cached(id string, name string) *models.Device {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.names[id] != name {
+		return nil
+	}
+
+	return d.devices[name]
+}
+
 // SetDeviceOpState sets the operatingState of the device specified by name.
 func (d *deviceCache) SetDeviceOpState(name string, os models.OperatingState) error {
 	return nil
@@ -177,27 +274,72 @@ func (d *deviceCache) SetDeviceByIdOpState(id string, os models.OperatingState)
 // Update updates the device in the cache and ensures that the
 // copy in Core Metadata is also updated.
 func (d *deviceCache) Update(dev *models.Device) error {
-	err := svc.dc.Update(*dev)
+	spec, _, err := ParseAddressable(&dev.Addressable)
+	if err != nil {
+		return err
+	}
+
+	err = svc.dc.Update(*dev)
 	if err != nil {
 		return err
 	}
 
 	// consider device name can be modified, so remove the old one and put new one
+	d.mutex.Lock()
 	if _, ok := d.names[dev.Id.Hex()]; ok {
 		delete(d.devices, d.names[dev.Id.Hex()])
 	}
 	d.devices[dev.Name] = dev
 	d.names[dev.Id.Hex()] = dev.Name
+	d.mutex.Unlock()
+
+	if l, ok := deviceLifecycle(); ok {
+		err = l.DeviceUpdated(dev, spec)
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("ProtocolDriver.DeviceUpdated: %s; failed: %v\n", dev.Name, err))
+		}
+	}
 
 	return nil
 }
 
+// Replace replaces a cached device with the given copy, without updating
+// Core Metadata. It does nothing if the device has been removed.
+func (d *deviceCache) Replace(dev *models.Device) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if _, ok := d.devices[dev.Name]; ok {
+		d.devices[dev.Name] = dev
+	}
+}
+
+// deviceLifecycle returns the ProtocolDriver's DeviceLifecycle hooks, if
+// it implements them.
+func deviceLifecycle() (DeviceLifecycle, bool) {
+	l, ok := svc.proto.(DeviceLifecycle)
+	return l, ok
+}
+
+// notifyDeviceRemoved calls the ProtocolDriver's DeviceRemoved hook, if any.
+func notifyDeviceRemoved(dev *models.Device) {
+	l, ok := deviceLifecycle()
+	if !ok || dev == nil {
+		return
+	}
+
+	err := l.DeviceRemoved(dev)
+	if err != nil {
+		svc.lc.Error(fmt.Sprintf("ProtocolDriver.DeviceRemoved: %s; failed: %v\n", dev.Name, err))
+	}
+}
+
 // UpdateAdminState updates the device admin state in cache by id. This method
 // is used by the UpdateHandler to trigger update device admin state that's been
 // updated directly to Core Metadata.
 func (d *deviceCache) UpdateAdminState(id string) error {
-	name, ok := d.names[id]
-	if !ok {
+	cached := d.DeviceById(id)
+	if cached == nil {
 		return errors.New("Device not found")
 	}
 	dev, err := svc.dc.Device(id)
@@ -205,7 +347,9 @@ func (d *deviceCache) UpdateAdminState(id string) error {
 		return err
 	}
 
-	d.devices[name].AdminState = dev.AdminState
+	updated := *cached
+	updated.AdminState = dev.AdminState
+	d.Replace(&updated)
 	return nil
 }
 
@@ -213,8 +357,9 @@ func (d *deviceCache) UpdateAdminState(id string) error {
 // functions, one which validates an existing device and adds
 // it to the local cache, and one that adds a brand new device.
 // The current method is an almost direct translation of the Java
-// DeviceStore implementation.
-func (d *deviceCache) addDeviceToMetadata(dev *models.Device) error {
+// DeviceStore implementation. The ConnectionSpec of the device's
+// Addressable is returned (see addressableSpec).
+func (d *deviceCache) addDeviceToMetadata(dev *models.Device) (ConnectionSpec, error) {
 	// TODO: fix metadata to indicate !found, vs. returned zeroed struct!
 	svc.lc.Debug(fmt.Sprintf("Trying to find addressable for: %s\n", dev.Addressable.Name))
 	addr, err := svc.ac.AddressableForName(dev.Addressable.Name)
@@ -223,20 +368,29 @@ func (d *deviceCache) addDeviceToMetadata(dev *models.Device) error {
 
 		// If device exists in metadata, and lacks an Addressable, don't try to fix; skip instead
 		if dev.Id.Valid() {
-			return fmt.Errorf("Existing metadata dev has no addressable: %s", dev.Addressable.Name)
+			return nil, fmt.Errorf("Existing metadata dev has no addressable: %s", dev.Addressable.Name)
 		}
 	}
 
 	// TODO: this is the best test for not-found for now...
-	if addr.Name != dev.Addressable.Name {
+	newAddr := addr.Name != dev.Addressable.Name
+	if newAddr {
 		addr = dev.Addressable
+	}
+
+	spec, err := addressableSpec(dev, &addr, dev.Id.Valid() && !newAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if newAddr {
 		addr.BaseObject.Origin = time.Now().UnixNano() / int64(time.Millisecond)
 		svc.lc.Debug(fmt.Sprintf("Creating new Addressable Object with name: %v", addr))
 
 		id, err := svc.ac.Add(&addr)
 		if err != nil {
 			svc.lc.Error(fmt.Sprintf("AddressClient.Add: %s; failed: %v\n", dev.Addressable.Name, err))
-			return err
+			return nil, err
 		}
 
 		// TODO: add back length check in from non-public metadata-clients logic
@@ -244,7 +398,7 @@ func (d *deviceCache) addDeviceToMetadata(dev *models.Device) error {
 		// if len(bodyBytes) != 24 || !bson.IsObjectIdHex(bodyString) {
 		//
 		if !bson.IsObjectIdHex(id) {
-			return fmt.Errorf("Add addressable returned invalid Id: %s\n", id)
+			return nil, fmt.Errorf("Add addressable returned invalid Id: %s\n", id)
 		} else {
 			addr.Id = bson.ObjectIdHex(id)
 			svc.lc.Debug(fmt.Sprintf("New addressable Id: %s\n", addr.Id.Hex()))
@@ -266,7 +420,7 @@ func (d *deviceCache) addDeviceToMetadata(dev *models.Device) error {
 			id, err := svc.dc.Add(dev)
 			if err != nil {
 				svc.lc.Error(fmt.Sprintf("DeviceClient.Add for %s failed: %v", dev.Name, err))
-				return err
+				return nil, err
 			}
 
 			// TODO: add back length check in from non-public metadata-clients logic
@@ -274,7 +428,7 @@ func (d *deviceCache) addDeviceToMetadata(dev *models.Device) error {
 			// if len(bodyBytes) != 24 || !bson.IsObjectIdHex(bodyString) {
 			//
 			if !bson.IsObjectIdHex(id) {
-				return fmt.Errorf("DeviceClient Add returned invalid id: %s\n", id)
+				return nil, fmt.Errorf("DeviceClient Add returned invalid id: %s\n", id)
 			} else {
 				dev.Id = bson.ObjectIdHex(id)
 				svc.lc.Debug(fmt.Sprintf("New dev id: %s\n", dev.Id.Hex()))
@@ -295,13 +449,35 @@ func (d *deviceCache) addDeviceToMetadata(dev *models.Device) error {
 
 	err = pc.addDevice(dev)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	d.mutex.Lock()
 	d.devices[dev.Name] = dev
 	d.names[dev.Id.Hex()] = dev.Name
+	d.mutex.Unlock()
 
-	return nil
+	return spec, nil
+}
+
+// addressableSpec returns the ConnectionSpec of the given Addressable of
+// dev. An invalid Addressable is an error for a new device, but a device
+// which already exists in Core Metadata, e.g. one loaded at start-up, is
+// kept with a nil spec, so that devices added before addressables were
+// validated aren't lost.
+func addressableSpec(dev *models.Device, addr *models.Addressable, existing bool) (ConnectionSpec, error) {
+	spec, _, err := ParseAddressable(addr)
+	if err == nil {
+		return spec, nil
+	}
+
+	if existing {
+		svc.lc.Warn(fmt.Sprintf("dev: %s has invalid addressable; %v\n", dev.Name, err))
+		return nil, nil
+	}
+
+	svc.lc.Error(fmt.Sprintf("dev: %s has invalid addressable; %v\n", dev.Name, err))
+	return nil, err
 }
 
 // FIXME: !threadsafe - none of the compare methods are threadsafe
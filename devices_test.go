@@ -8,10 +8,13 @@
 package device
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/edgexfoundry/device-sdk-go/mock"
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
 	"github.com/edgexfoundry/edgex-go/pkg/models"
+	"gopkg.in/mgo.v2/bson"
 )
 
 // TODO:
@@ -94,3 +97,44 @@ func TestUpdateDevice(t *testing.T) {
 	}
 
 }
+
+func TestRemoveDeviceConcurrently(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		driver := &lifecycleDriver{}
+		svc = &Service{c: &Config{}, lc: logger.NewClient("devices_test", false, ""), proto: driver}
+		svc.dc = &mock.DeviceClientMock{}
+		pc = &profileCache{objects: map[string]map[string]models.DeviceObject{}, commands: map[string]map[string]map[string][]models.ResourceOperation{}}
+		ot = newOperationTracker()
+
+		dev := &models.Device{Id: bson.NewObjectId(), Name: "meter"}
+		dc = &deviceCache{
+			devices: map[string]*models.Device{"meter": dev},
+			names:   map[string]string{dev.Id.Hex(): "meter"},
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs[0] = dc.Remove(dev)
+		}()
+		go func() {
+			defer wg.Done()
+			errs[1] = dc.RemoveById(dev.Id.Hex())
+		}()
+		wg.Wait()
+
+		if (errs[0] == nil) == (errs[1] == nil) {
+			t.Fatalf("Remove, RemoveById: expected exactly one removal, got errors: %v", errs)
+		}
+
+		if len(driver.calls) != 1 || driver.calls[0] != "removed meter" {
+			t.Fatalf("Remove, RemoveById: expected one DeviceRemoved call, got: %v", driver.calls)
+		}
+
+		if len(dc.Devices()) != 0 {
+			t.Fatalf("Remove, RemoveById: device still cached")
+		}
+	}
+}
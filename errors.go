@@ -0,0 +1,118 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// correlationHeader is the HTTP header used to pass a correlation id,
+// which identifies a request in logs and error responses.
+const correlationHeader = "X-Correlation-ID"
+
+// ErrorResponse is the JSON body returned by all REST endpoints on error.
+type ErrorResponse struct {
+	// Code is the HTTP status code of the response.
+	Code int `json:"code"`
+	// Message describes the error.
+	Message string `json:"message"`
+	// Device is the name (or id, if the device wasn't found) of the device
+	// the request was for, if any.
+	Device string `json:"device,omitempty"`
+	// Command is the command the request was for, if any.
+	Command string `json:"command,omitempty"`
+	// CorrelationId is the correlation id of the request.
+	CorrelationId string `json:"correlationId,omitempty"`
+}
+
+// CommandError is an error which a ProtocolDriver can return from
+// HandleCommands to select the HTTP status code of the command response.
+// Other errors result in a 500 (Internal Server Error).
+type CommandError struct {
+	Code    int
+	Message string
+}
+
+func (e CommandError) Error() string {
+	return e.Message
+}
+
+// NewBadParameterError returns a CommandError indicating that the
+// command parameters are invalid (400).
+func NewBadParameterError(msg string) error {
+	return CommandError{Code: http.StatusBadRequest, Message: msg}
+}
+
+// NewNotSupportedError returns a CommandError indicating that the
+// device doesn't support the command (501).
+func NewNotSupportedError(msg string) error {
+	return CommandError{Code: http.StatusNotImplemented, Message: msg}
+}
+
+// NewDeviceUnreachableError returns a CommandError indicating that the
+// device couldn't be contacted (503).
+func NewDeviceUnreachableError(msg string) error {
+	return CommandError{Code: http.StatusServiceUnavailable, Message: msg}
+}
+
+// NewDeviceTimeoutError returns a CommandError indicating that the
+// device didn't respond in time (504).
+func NewDeviceTimeoutError(msg string) error {
+	return CommandError{Code: http.StatusGatewayTimeout, Message: msg}
+}
+
+// correlationHandler wraps the given handler, setting the correlation
+// id response header from the request, or to a new id if the request
+// doesn't have one.
+func correlationHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(correlationHeader)
+		if id == "" {
+			id = bson.NewObjectId().Hex()
+		}
+
+		w.Header().Set(correlationHeader, id)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// writeError writes an ErrorResponse with the given status code. The
+// device and command may be empty if not applicable to the request.
+func writeError(w http.ResponseWriter, code int, msg string, device string, command string) {
+	rsp := ErrorResponse{
+		Code:          code,
+		Message:       msg,
+		Device:        device,
+		Command:       command,
+		CorrelationId: w.Header().Get(correlationHeader),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(rsp)
+}
+
+// notFoundHandler writes an error response for a request which doesn't
+// match any route.
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	msg := fmt.Sprintf("route not found; %s %s", r.Method, r.URL)
+	svc.lc.Error(msg)
+	writeError(w, http.StatusNotFound, msg, "", "") // status=404
+}
+
+// methodNotAllowedHandler writes an error response for a request whose
+// route doesn't support its method.
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	msg := fmt.Sprintf("method not allowed; %s %s", r.Method, r.URL)
+	svc.lc.Error(msg)
+	writeError(w, http.StatusMethodNotAllowed, msg, "", "") // status=405
+}
@@ -0,0 +1,112 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/gorilla/mux"
+)
+
+func TestCorrelatedError(t *testing.T) {
+	h := correlationHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusServiceUnavailable, "unreachable", "dev", "cmd") // status=503
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(correlationHeader, "1234")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("writeError: wrong status code: %d or content type", rr.Code)
+	}
+
+	var rsp ErrorResponse
+	err := json.NewDecoder(rr.Body).Decode(&rsp)
+	if err != nil {
+		t.Fatalf("writeError: invalid JSON: %v", err)
+	}
+
+	expected := ErrorResponse{Code: 503, Message: "unreachable", Device: "dev", Command: "cmd", CorrelationId: "1234"}
+	if rsp != expected {
+		t.Errorf("writeError: expected: %v, got: %v", expected, rsp)
+	}
+
+	// a correlation id is generated if the request doesn't have one
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Header().Get(correlationHeader) == "" {
+		t.Errorf("correlationHandler: no correlation id generated")
+	}
+}
+
+func TestCommandErrorStatus(t *testing.T) {
+	var tests = []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"bad parameter", NewBadParameterError("bad"), http.StatusBadRequest},
+		{"not supported", NewNotSupportedError("unsupported"), http.StatusNotImplemented},
+		{"unreachable", NewDeviceUnreachableError("unreachable"), http.StatusServiceUnavailable},
+		{"timeout", NewDeviceTimeoutError("timeout"), http.StatusGatewayTimeout},
+		{"other", errors.New("failed"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := commandErrorStatus(tt.err); code != tt.code {
+				t.Errorf("commandErrorStatus: expected: %d, got: %d", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestUnroutedErrors(t *testing.T) {
+	r := mux.NewRouter().PathPrefix(apiV1).Subrouter()
+	r.NotFoundHandler = http.HandlerFunc(notFoundHandler)
+	r.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowedHandler)
+	svc = &Service{Name: "errors-test", c: &Config{}, lc: logger.NewClient("errors_test", false, ""), r: r}
+	initStatus()
+	initCommand()
+
+	var tests = []struct {
+		name   string
+		method string
+		path   string
+		code   int
+	}{
+		{"unknown path", http.MethodGet, apiV1 + "/unknown", http.StatusNotFound},
+		{"outside API", http.MethodGet, "/unknown", http.StatusNotFound},
+		{"unknown device path", http.MethodGet, v1Device + "/1/reading/a/b", http.StatusNotFound},
+		{"bad method", http.MethodPost, apiV1 + "/stats", http.StatusMethodNotAllowed},
+		{"bad device method", http.MethodDelete, v1Device + "/1/cmd", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			correlationHandler(svc.r).ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rr.Code != tt.code || rr.Header().Get("Content-Type") != "application/json" {
+				t.Fatalf("%s %s: expected JSON status: %d, got: %d %s", tt.method, tt.path, tt.code, rr.Code, rr.Header().Get("Content-Type"))
+			}
+
+			var rsp ErrorResponse
+			err := json.NewDecoder(rr.Body).Decode(&rsp)
+			if err != nil || rsp.Code != tt.code || rsp.CorrelationId == "" {
+				t.Errorf("%s %s: wrong error response: %+v; err: %v", tt.method, tt.path, rsp, err)
+			}
+		})
+	}
+}
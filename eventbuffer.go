@@ -0,0 +1,287 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+// spillSuffix is appended to the EventBufferSpillFile name when it's
+// rotated.
+const spillSuffix = ".1"
+
+// eventBuffer holds the most recent events produced by the DS, regardless
+// of whether they were pushed to Core Data. Events are held in memory in a
+// ring buffer, and optionally spilled to a file when evicted from it. The
+// spill file is rotated once it exceeds its maximum size, keeping a single
+// previous file, so disk usage is bounded too.
+type eventBuffer struct {
+	mutex  sync.Mutex
+	events []models.Event
+	// start is the index of the oldest event in events
+	start int
+	count int
+	// spillPath is the spill file, or empty if spilling is disabled
+	spillPath string
+	spillMax  int64
+}
+
+// eventQuery specifies the events returned by eventBuffer.query.
+type eventQuery struct {
+	device   string
+	resource string
+	since    int64
+	limit    int
+}
+
+// eb is nil if the event buffer is disabled.
+var eb *eventBuffer
+
+func newEventBuffer(size int, spillPath string, spillMax int64) *eventBuffer {
+	return &eventBuffer{events: make([]models.Event, size), spillPath: spillPath, spillMax: spillMax}
+}
+
+// add adds an event to the buffer, evicting the oldest event if the
+// buffer is full.
+func (b *eventBuffer) add(event *models.Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.count < len(b.events) {
+		b.events[(b.start+b.count)%len(b.events)] = *event
+		b.count++
+		return
+	}
+
+	if b.spillPath != "" {
+		err := b.spill(&b.events[b.start])
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("failed to spill event to: %s; %v", b.spillPath, err))
+		}
+	}
+
+	b.events[b.start] = *event
+	b.start = (b.start + 1) % len(b.events)
+}
+
+// spill appends the given event to the spill file, rotating the file if
+// it exceeds spillMax. The buffer must be locked.
+func (b *eventBuffer) spill(event *models.Event) error {
+	f, err := os.OpenFile(b.spillPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = json.NewEncoder(f).Encode(event)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if b.spillMax > 0 && fi.Size() > b.spillMax {
+		return os.Rename(b.spillPath, b.spillPath+spillSuffix)
+	}
+
+	return nil
+}
+
+// query returns the buffered events which match the given query, oldest
+// first. If the resource is specified, only readings of that resource are
+// included. If the limit is positive, only the most recent events are
+// returned. The buffer is only locked while the events in memory are
+// copied and the spill files opened, so that commands aren't held up
+// while the spill files are read.
+func (b *eventBuffer) query(q eventQuery) []models.Event {
+	var matched []models.Event
+	match := func(e models.Event) {
+		if e, ok := q.match(e); ok {
+			matched = append(matched, e)
+		}
+	}
+
+	b.mutex.Lock()
+	events := make([]models.Event, b.count)
+	for i := range events {
+		events[i] = b.events[(b.start+i)%len(b.events)]
+	}
+	spills := b.openSpillFiles()
+	b.mutex.Unlock()
+
+	for _, s := range spills {
+		err := readSpillFile(s.reader, match)
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("failed to read spilled events from: %s; %v", s.path, err))
+		}
+		s.file.Close()
+	}
+
+	for _, e := range events {
+		match(e)
+	}
+
+	if q.limit > 0 && len(matched) > q.limit {
+		matched = matched[len(matched)-q.limit:]
+	}
+
+	return matched
+}
+
+// spillFile is a spill file opened by openSpillFiles.
+type spillFile struct {
+	path string
+	file *os.File
+	// reader is limited to the contents of the file when it was opened,
+	// so events spilled later aren't read as well as copied from memory
+	reader io.Reader
+}
+
+// openSpillFiles opens the rotated and current spill files, oldest
+// first, if they exist. The buffer must be locked, so that the files
+// aren't rotated or appended to while they're opened.
+func (b *eventBuffer) openSpillFiles() []spillFile {
+	if b.spillPath == "" {
+		return nil
+	}
+
+	var spills []spillFile
+	for _, path := range []string{b.spillPath + spillSuffix, b.spillPath} {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		var fi os.FileInfo
+		if err == nil {
+			fi, err = f.Stat()
+			if err != nil {
+				f.Close()
+			}
+		}
+
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("failed to read spilled events from: %s; %v", path, err))
+			continue
+		}
+
+		spills = append(spills, spillFile{path: path, file: f, reader: io.NewSectionReader(f, 0, fi.Size())})
+	}
+
+	return spills
+}
+
+// readSpillFile calls fn for each event read from a spill file.
+func readSpillFile(r io.Reader, fn func(models.Event)) error {
+	dec := json.NewDecoder(r)
+	for {
+		var e models.Event
+		err := dec.Decode(&e)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		fn(e)
+	}
+}
+
+// match returns whether the given event matches the query, and the event
+// with its readings filtered by resource.
+func (q eventQuery) match(e models.Event) (models.Event, bool) {
+	if q.device != "" && e.Device != q.device {
+		return e, false
+	}
+
+	if e.Origin < q.since {
+		return e, false
+	}
+
+	if q.resource == "" {
+		return e, true
+	}
+
+	var readings []models.Reading
+	for _, r := range e.Readings {
+		if r.Name == q.resource {
+			readings = append(readings, r)
+		}
+	}
+
+	e.Readings = readings
+	return e, len(readings) > 0
+}
+
+// parseEventQuery returns the eventQuery specified by the given query
+// parameters.
+func parseEventQuery(query url.Values) (eventQuery, error) {
+	q := eventQuery{device: query.Get("device"), resource: query.Get("resource")}
+
+	var err error
+	if s := query.Get("since"); s != "" {
+		q.since, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return q, fmt.Errorf("invalid since: %s", s)
+		}
+	}
+
+	if s := query.Get("limit"); s != "" {
+		q.limit, err = strconv.Atoi(s)
+		if err != nil || q.limit < 0 {
+			return q, fmt.Errorf("invalid limit: %s", s)
+		}
+	}
+
+	return q, nil
+}
+
+func eventsFunc(w http.ResponseWriter, r *http.Request) {
+	if eb == nil {
+		msg := fmt.Sprintf("event buffer not enabled; %s %s", r.Method, r.URL)
+		svc.lc.Error(msg)
+		writeError(w, http.StatusNotFound, msg, "", "") // status=404
+		return
+	}
+
+	q, err := parseEventQuery(r.URL.Query())
+	if err != nil {
+		msg := fmt.Sprintf("%v; %s %s", err, r.Method, r.URL)
+		svc.lc.Error(msg)
+		writeError(w, http.StatusBadRequest, msg, q.device, "") // status=400
+		return
+	}
+
+	events := eb.query(q)
+	if events == nil {
+		events = []models.Event{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+func initEvents() {
+	if svc.c.Device.EventBufferSize > 0 {
+		eb = newEventBuffer(svc.c.Device.EventBufferSize, svc.c.Device.EventBufferSpillFile,
+			int64(svc.c.Device.EventBufferSpillMaxSize))
+	}
+
+	svc.r.HandleFunc("/events", eventsFunc).Methods(http.MethodGet)
+}
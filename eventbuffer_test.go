@@ -0,0 +1,167 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+func bufferedEvent(dev string, origin int64, names ...string) *models.Event {
+	e := &models.Event{Device: dev, Origin: origin}
+	for _, name := range names {
+		e.Readings = append(e.Readings, models.Reading{Device: dev, Name: name, Value: "1", Origin: origin})
+	}
+
+	return e
+}
+
+func TestEventBufferQuery(t *testing.T) {
+	b := newEventBuffer(3, "", 0)
+	for i := int64(1); i <= 4; i++ {
+		b.add(bufferedEvent("dev", i, "Temperature", "Humidity"))
+	}
+	b.add(bufferedEvent("other", 5, "Temperature"))
+
+	// the buffer holds the 3 most recent events
+	events := b.query(eventQuery{})
+	if len(events) != 3 || events[0].Origin != 3 || events[2].Origin != 5 {
+		t.Fatalf("query: wrong events returned: %v", events)
+	}
+
+	events = b.query(eventQuery{device: "dev", resource: "Humidity"})
+	if len(events) != 2 || len(events[0].Readings) != 1 || events[0].Readings[0].Name != "Humidity" {
+		t.Errorf("query: wrong events returned for device and resource: %v", events)
+	}
+
+	events = b.query(eventQuery{since: 4, limit: 1})
+	if len(events) != 1 || events[0].Origin != 5 {
+		t.Errorf("query: wrong events returned for since and limit: %v", events)
+	}
+}
+
+func TestEventBufferSpill(t *testing.T) {
+	svc = &Service{c: &Config{}, lc: logger.NewClient("eventbuffer_test", false, "")}
+
+	dir, err := ioutil.TempDir("", "eventbuffer")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "events.json")
+	b := newEventBuffer(2, path, 1)
+	for i := int64(1); i <= 5; i++ {
+		b.add(bufferedEvent("dev", i, "Temperature"))
+	}
+
+	// with a tiny maximum size, each spilled event rotates the file,
+	// so only the last spilled event is kept
+	events := b.query(eventQuery{})
+	if len(events) != 3 || events[0].Origin != 3 || events[2].Origin != 5 {
+		t.Errorf("query: wrong events returned with spill file: %v", events)
+	}
+
+	b = newEventBuffer(2, path+"2", 0)
+	for i := int64(1); i <= 5; i++ {
+		b.add(bufferedEvent("dev", i, "Temperature"))
+	}
+
+	events = b.query(eventQuery{})
+	if len(events) != 5 || events[0].Origin != 1 {
+		t.Errorf("query: wrong events returned with unbounded spill file: %v", events)
+	}
+}
+
+func TestEventBufferSpillSnapshot(t *testing.T) {
+	svc = &Service{c: &Config{}, lc: logger.NewClient("eventbuffer_test", false, "")}
+
+	dir, err := ioutil.TempDir("", "eventbuffer")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	b := newEventBuffer(1, filepath.Join(dir, "events.json"), 1000)
+	for i := int64(1); i <= 3; i++ {
+		b.add(bufferedEvent("dev", i, "Temperature"))
+	}
+
+	b.mutex.Lock()
+	spills := b.openSpillFiles()
+	b.mutex.Unlock()
+
+	// events spilled, and files rotated, while the files are being read
+	// by a query aren't seen by it
+	for i := int64(4); i <= 20; i++ {
+		b.add(bufferedEvent("dev", i, "Temperature"))
+	}
+
+	var origins []int64
+	for _, s := range spills {
+		err := readSpillFile(s.reader, func(e models.Event) { origins = append(origins, e.Origin) })
+		s.file.Close()
+		if err != nil {
+			t.Fatalf("readSpillFile: unexpected error: %v", err)
+		}
+	}
+
+	if len(origins) != 2 || origins[0] != 1 || origins[1] != 2 {
+		t.Errorf("openSpillFiles: expected events 1 and 2, got: %v", origins)
+	}
+}
+
+func TestExecuteCommandPutNotBuffered(t *testing.T) {
+	svc = &Service{c: &Config{Device: DeviceInfo{MaxCmdOps: 128}}, lc: logger.NewClient("eventbuffer_test", false, ""), proto: &paramsDriver{}}
+	pc = &profileCache{
+		objects: map[string]map[string]models.DeviceObject{"dev": {"switch": {Name: "switch"}}},
+		commands: map[string]map[string]map[string][]models.ResourceOperation{
+			"dev": {"switch": {"set": {{Object: "switch", Parameter: "switch"}}}},
+		},
+	}
+
+	eb = newEventBuffer(3, "", 0)
+	defer func() { eb = nil }()
+	eb.add(bufferedEvent("dev", 1, "switch"))
+
+	for _, opts := range []commandOptions{{}, {pushEvent: true}} {
+		rr := httptest.NewRecorder()
+		executeCommand(context.Background(), rr, &models.Device{Name: "dev"}, "switch", http.MethodPut, `{"switch":"on"}`, opts)
+		if rr.Code != http.StatusNoContent {
+			t.Fatalf("executeCommand: expected status: %d, got: %d %s", http.StatusNoContent, rr.Code, rr.Body.String())
+		}
+	}
+
+	events := eb.query(eventQuery{})
+	if len(events) != 1 || events[0].Origin != 1 {
+		t.Errorf("executeCommand: expected PUT to leave the event buffer unchanged, got: %v", events)
+	}
+}
+
+func TestParseEventQuery(t *testing.T) {
+	query, _ := url.ParseQuery("device=dev&resource=Temperature&since=100&limit=10")
+	q, err := parseEventQuery(query)
+	if err != nil || q != (eventQuery{"dev", "Temperature", 100, 10}) {
+		t.Errorf("parseEventQuery: wrong query: %v; err: %v", q, err)
+	}
+
+	for _, s := range []string{"since=yesterday", "limit=-1"} {
+		query, _ = url.ParseQuery(s)
+		if _, err = parseEventQuery(query); err == nil {
+			t.Errorf("parseEventQuery: invalid query accepted: %s", s)
+		}
+	}
+}
@@ -9,6 +9,7 @@
 package simple
 
 import (
+	"context"
 	"fmt"
 
 	device "github.com/edgexfoundry/device-sdk-go"
@@ -38,7 +39,7 @@ func (s *SimpleDriver) Initialize(svc *device.Service, lc logger.LoggingClient,
 
 // HandleCommand triggers an asynchronous protocol specific GET or SET operation
 // for the specified device.
-func (s *SimpleDriver) HandleCommands(d models.Device, reqs []device.CommandRequest,
+func (s *SimpleDriver) HandleCommands(ctx context.Context, d models.Device, reqs []device.CommandRequest,
 	params string) (res []device.CommandResult, err error) {
 
 	if len(reqs) != 1 {
@@ -0,0 +1,130 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+const (
+	// FloatEncodingAttribute is the DeviceObject attribute which specifies
+	// how float readings of the object are encoded in Reading.Value; one of
+	// FloatEncodingDecimal (the default), FloatEncodingScientific or
+	// FloatEncodingBase64. The number of digits after the decimal point is
+	// set by the PropertyValue's Precision; by default, the fewest digits
+	// which represent the value exactly are used.
+	FloatEncodingAttribute = "floatEncoding"
+
+	// FloatEncodingDecimal encodes floats in decimal notation, e.g. 9.81.
+	FloatEncodingDecimal = "decimal"
+	// FloatEncodingScientific encodes floats in scientific notation,
+	// e.g. 9.81e+00.
+	FloatEncodingScientific = "scientific"
+	// FloatEncodingBase64 encodes the big-endian IEEE-754 bytes of
+	// floats in base64, e.g. QBz1wo9cKPY=.
+	FloatEncodingBase64 = "base64"
+)
+
+// floatEncoding is the float encoding configuration of a DeviceObject.
+type floatEncoding struct {
+	format string
+	// precision is -1 for the fewest digits needed
+	precision int
+}
+
+// floatEncodingOf returns the float encoding configuration of the given
+// DeviceObject.
+func floatEncodingOf(devObj *models.DeviceObject) (floatEncoding, error) {
+	enc := floatEncoding{format: FloatEncodingDecimal, precision: -1}
+
+	if f, ok := AttributeString(devObj.Attributes, FloatEncodingAttribute); ok {
+		switch f {
+		case FloatEncodingDecimal, FloatEncodingScientific, FloatEncodingBase64:
+			enc.format = f
+		default:
+			return enc, fmt.Errorf("devobject: %s has invalid %s: %s", devObj.Name, FloatEncodingAttribute, f)
+		}
+	}
+
+	if p := devObj.Properties.Value.Precision; p != "" {
+		precision, err := strconv.Atoi(p)
+		if err != nil || precision < 0 {
+			return enc, fmt.Errorf("devobject: %s has invalid precision: %s", devObj.Name, p)
+		}
+		enc.precision = precision
+	}
+
+	return enc, nil
+}
+
+// floatString returns the value of a float or float array result in the
+// given encoding. Arrays are encoded as a JSON array, unless encoded in
+// base64, in which case the bytes of all elements are encoded. False is
+// returned if the result isn't a float or float array.
+func (cr *CommandResult) floatString(enc floatEncoding) (string, bool) {
+	elem, isArray := arrayElementType(cr.Type)
+	if elem != Float32 && elem != Float64 {
+		return "", false
+	}
+
+	if enc.format == FloatEncodingBase64 {
+		return base64.StdEncoding.EncodeToString(cr.NumericResult), true
+	}
+
+	fmtByte := byte('f')
+	if enc.format == FloatEncodingScientific {
+		fmtByte = 'e'
+	}
+
+	bits := resultBits(elem)
+	var strs []string
+	for b := cr.NumericResult; len(b) >= bits/8; b = b[bits/8:] {
+		var f float64
+		if elem == Float32 {
+			f = float64(math.Float32frombits(uint32(getUint(b[:4]))))
+		} else {
+			f = math.Float64frombits(getUint(b[:8]))
+		}
+
+		strs = append(strs, strconv.FormatFloat(f, fmtByte, enc.precision, bits))
+	}
+
+	if isArray {
+		return "[" + strings.Join(strs, ",") + "]", true
+	}
+
+	if len(strs) != 1 {
+		return "", false
+	}
+
+	return strs[0], true
+}
+
+// newReading returns the Reading of the given CommandResult, with its
+// value encoded as configured by the DeviceObject. The origin set by the
+// ProtocolDriver, if any, is checked for clock drift.
+func newReading(cr *CommandResult, devName string, devObj *models.DeviceObject) *models.Reading {
+	dt.check(devName, cr.Origin)
+	reading := cr.Reading(devName, devObj.Name)
+
+	enc, err := floatEncodingOf(devObj)
+	if err != nil {
+		svc.lc.Error(err.Error())
+	}
+
+	if f, ok := cr.floatString(enc); ok {
+		reading.Value = f
+	}
+
+	return reading
+}
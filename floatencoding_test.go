@@ -0,0 +1,79 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+func TestFloatEncodingOf(t *testing.T) {
+	var tests = []struct {
+		name      string
+		attrs     interface{}
+		precision string
+		expected  floatEncoding
+		valid     bool
+	}{
+		{"Default", nil, "", floatEncoding{FloatEncodingDecimal, -1}, true},
+		{"Precision", nil, "2", floatEncoding{FloatEncodingDecimal, 2}, true},
+		{"Scientific", map[string]interface{}{FloatEncodingAttribute: "scientific"}, "3", floatEncoding{FloatEncodingScientific, 3}, true},
+		{"Base64", map[interface{}]interface{}{FloatEncodingAttribute: "base64"}, "", floatEncoding{FloatEncodingBase64, -1}, true},
+		{"Invalid encoding", map[string]string{FloatEncodingAttribute: "hex"}, "", floatEncoding{}, false},
+		{"Invalid precision", nil, "-1", floatEncoding{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			do := &models.DeviceObject{Name: "temperature", Attributes: tt.attrs}
+			do.Properties.Value.Precision = tt.precision
+
+			enc, err := floatEncodingOf(do)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("floatEncodingOf: expected error")
+				}
+				return
+			}
+
+			if err != nil || enc != tt.expected {
+				t.Errorf("floatEncodingOf: expected: %v, got: %v, %v", tt.expected, enc, err)
+			}
+		})
+	}
+}
+
+func TestFloatString(t *testing.T) {
+	var tests = []struct {
+		name     string
+		cr       *CommandResult
+		enc      floatEncoding
+		expected string
+	}{
+		{"Float32 default", NewFloat32Result(nil, nil, 0, 0.1), floatEncoding{FloatEncodingDecimal, -1}, "0.1"},
+		{"Float64 default", NewFloat64Result(nil, nil, 0, 9.81), floatEncoding{FloatEncodingDecimal, -1}, "9.81"},
+		{"Float64 precision", NewFloat64Result(nil, nil, 0, 9.816), floatEncoding{FloatEncodingDecimal, 2}, "9.82"},
+		{"Float64 scientific", NewFloat64Result(nil, nil, 0, 1234.5), floatEncoding{FloatEncodingScientific, 2}, "1.23e+03"},
+		{"Float64 base64", NewFloat64Result(nil, nil, 0, 7.24), floatEncoding{FloatEncodingBase64, -1}, "QBz1wo9cKPY="},
+		{"Float32 base64", NewFloat32Result(nil, nil, 0, 1), floatEncoding{FloatEncodingBase64, -1}, "P4AAAA=="},
+		{"Float32 array", NewFloat32ArrayResult(nil, nil, 0, []float32{0.5, -1}), floatEncoding{FloatEncodingDecimal, 1}, "[0.5,-1.0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, ok := tt.cr.floatString(tt.enc)
+			if !ok || s != tt.expected {
+				t.Errorf("floatString: expected: %s, got: %s", tt.expected, s)
+			}
+		})
+	}
+
+	if _, ok := NewInt32Result(nil, nil, 0, 1).floatString(floatEncoding{FloatEncodingDecimal, -1}); ok {
+		t.Errorf("floatString: expected failure for Int32 result")
+	}
+}
@@ -0,0 +1,133 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+const (
+	// MaxCmdValueLenReject causes a command to fail if the driver returns
+	// a result which exceeds MaxCmdValueLen.
+	MaxCmdValueLenReject = "reject"
+	// MaxCmdValueLenTruncate causes a result which exceeds MaxCmdValueLen
+	// to be truncated.
+	MaxCmdValueLenTruncate = "truncate"
+
+	// jsonParamOverhead is the maximum JSON encoding overhead of a single
+	// command parameter, i.e. quotes, a colon, a comma and whitespace.
+	jsonParamOverhead = 8
+	// defaultMaxRequestSize is used to limit request bodies if
+	// MaxCmdValueLen or MaxCmdOps isn't configured.
+	defaultMaxRequestSize = 1024 * 1024
+)
+
+var errRequestTooLarge = errors.New("request body too large")
+
+// maxRequestSize returns the maximum size of a REST request body. A PUT
+// command can specify at most MaxCmdOps parameters, each of which is
+// limited to MaxCmdValueLen, so the limit is derived from these settings.
+func maxRequestSize() int64 {
+	ops := svc.c.Device.MaxCmdOps
+	valueLen := svc.c.Device.MaxCmdValueLen
+
+	if ops <= 0 || valueLen <= 0 {
+		return defaultMaxRequestSize
+	}
+
+	return int64(ops*(valueLen+jsonParamOverhead) + 2)
+}
+
+// readBody reads the body of the given request. If the body exceeds
+// maxRequestSize, errRequestTooLarge is returned.
+func readBody(r *http.Request) ([]byte, error) {
+	max := maxRequestSize()
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(body)) > max {
+		return nil, errRequestTooLarge
+	}
+
+	return body, nil
+}
+
+// checkValueLen enforces MaxCmdValueLen on the given reading, which has
+// the given result type. As per the setting, the limit includes the length
+// of the reading's name (i.e. the valuedescriptor name). If the limit is
+// exceeded, a String value is truncated at a character boundary if
+// MaxCmdValueLenPolicy is MaxCmdValueLenTruncate, otherwise an error is
+// returned. Binary, JSON and array values, which can't be cut without
+// corrupting them, are limited by MaxCmdBinaryValueLen if it's set.
+func checkValueLen(reading *models.Reading, t ResultType) error {
+	max, setting := svc.c.Device.MaxCmdValueLen, "MaxCmdValueLen"
+	if isStructuredType(t) && svc.c.Device.MaxCmdBinaryValueLen > 0 {
+		max, setting = svc.c.Device.MaxCmdBinaryValueLen, "MaxCmdBinaryValueLen"
+	}
+
+	if max <= 0 || len(reading.Name)+len(reading.Value) <= max {
+		return nil
+	}
+
+	if t == String && svc.c.Device.MaxCmdValueLenPolicy == MaxCmdValueLenTruncate {
+		n := max - len(reading.Name)
+		if n < 0 {
+			n = 0
+		}
+		// don't cut a multi-byte character in half
+		for n > 0 && !utf8.RuneStart(reading.Value[n]) {
+			n--
+		}
+
+		svc.lc.Warn(fmt.Sprintf("reading: %s for dev: %s truncated from %d to %d bytes",
+			reading.Name, reading.Device, len(reading.Value), n))
+		reading.Value = reading.Value[:n]
+		return nil
+	}
+
+	return fmt.Errorf("%v reading: %s for dev: %s exceeds %s (%d)", t, reading.Name, reading.Device, setting, max)
+}
+
+// isStructuredType returns whether results of the given type are Binary,
+// JSON or array values.
+func isStructuredType(t ResultType) bool {
+	_, isArray := arrayElementType(t)
+	return t == Binary || t == JSON || isArray
+}
+
+// splitEvent splits the given event into several events of at most max
+// readings each, all with the same device and origin. If max isn't
+// positive, or the event isn't too large, it's returned as-is.
+func splitEvent(event *models.Event, max int) []*models.Event {
+	if max <= 0 || len(event.Readings) <= max {
+		return []*models.Event{event}
+	}
+
+	var events []*models.Event
+	for start := 0; start < len(event.Readings); start += max {
+		end := start + max
+		if end > len(event.Readings) {
+			end = len(event.Readings)
+		}
+
+		e := *event
+		e.Readings = event.Readings[start:end]
+		events = append(events, &e)
+	}
+
+	return events
+}
@@ -0,0 +1,164 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+func newLimitsService(ops int, valueLen int, policy string) {
+	config := Config{}
+	config.Device.MaxCmdOps = ops
+	config.Device.MaxCmdValueLen = valueLen
+	config.Device.MaxCmdValueLenPolicy = policy
+	svc = &Service{Name: "limits-test", c: &config, lc: logger.NewClient("limits_test", false, "")}
+}
+
+func TestReadBody(t *testing.T) {
+	newLimitsService(2, 10, "")
+
+	max := maxRequestSize()
+	if max != 2*(10+jsonParamOverhead)+2 {
+		t.Errorf("maxRequestSize: wrong size: %d", max)
+	}
+
+	body := strings.Repeat("x", int(max))
+	b, err := readBody(httptest.NewRequest("PUT", "/", strings.NewReader(body)))
+	if err != nil || string(b) != body {
+		t.Errorf("readBody: body of max size rejected; err: %v", err)
+	}
+
+	_, err = readBody(httptest.NewRequest("PUT", "/", strings.NewReader(body+"x")))
+	if err != errRequestTooLarge {
+		t.Errorf("readBody: oversized body accepted; err: %v", err)
+	}
+
+	newLimitsService(0, 0, "")
+	if max := maxRequestSize(); max != defaultMaxRequestSize {
+		t.Errorf("maxRequestSize: expected default size, got: %d", max)
+	}
+}
+
+func TestCheckValueLen(t *testing.T) {
+	var tests = []struct {
+		name   string
+		policy string
+		value  string
+		want   string
+		err    bool
+	}{
+		{"short", MaxCmdValueLenReject, "12345", "12345", false},
+		{"reject", MaxCmdValueLenReject, "123456", "", true},
+		{"default", "", "123456", "", true},
+		{"truncate", MaxCmdValueLenTruncate, "123456", "12345", false},
+		{"truncate multi-byte", MaxCmdValueLenTruncate, "1234é", "1234", false},
+		{"truncate multi-byte only", MaxCmdValueLenTruncate, "ééé", "éé", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the limit includes the length of the reading name
+			newLimitsService(1, 10, tt.policy)
+			reading := &models.Reading{Device: "dev", Name: "Level", Value: tt.value}
+
+			err := checkValueLen(reading, String)
+			if tt.err {
+				if err == nil {
+					t.Errorf("checkValueLen: oversized value accepted")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("checkValueLen: unexpected error: %v", err)
+			} else if reading.Value != tt.want {
+				t.Errorf("checkValueLen: expected value: %s, got: %s", tt.want, reading.Value)
+			}
+		})
+	}
+}
+
+func TestCheckValueLenStructured(t *testing.T) {
+	var tests = []struct {
+		name   string
+		policy string
+		typ    ResultType
+		value  string
+	}{
+		{"Binary reject", MaxCmdValueLenReject, Binary, "data:image/png;base64,iVBORw0KGgo="},
+		{"Binary truncate", MaxCmdValueLenTruncate, Binary, "data:image/png;base64,iVBORw0KGgo="},
+		{"JSON reject", MaxCmdValueLenReject, JSON, `{"temperature":21.5,"humidity":40}`},
+		{"JSON truncate", MaxCmdValueLenTruncate, JSON, `{"temperature":21.5,"humidity":40}`},
+		{"Array truncate", MaxCmdValueLenTruncate, Int16Array, "[1,2,3,4,5,6,7,8,9,10,11,12]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newLimitsService(1, 20, tt.policy)
+			reading := &models.Reading{Device: "dev", Name: "Level", Value: tt.value}
+
+			// never truncated, as that would corrupt the value
+			err := checkValueLen(reading, tt.typ)
+			if err == nil || !strings.Contains(err.Error(), "exceeds MaxCmdValueLen (20)") {
+				t.Errorf("checkValueLen: expected oversized %v value rejected, got: %v", tt.typ, err)
+			}
+			if reading.Value != tt.value {
+				t.Errorf("checkValueLen: %v value changed to: %s", tt.typ, reading.Value)
+			}
+
+			// MaxCmdBinaryValueLen applies instead, if set
+			svc.c.Device.MaxCmdBinaryValueLen = 1000
+			if err := checkValueLen(reading, tt.typ); err != nil || reading.Value != tt.value {
+				t.Errorf("checkValueLen: expected %v value within MaxCmdBinaryValueLen accepted, got: %v", tt.typ, err)
+			}
+
+			svc.c.Device.MaxCmdBinaryValueLen = 30
+			err = checkValueLen(reading, tt.typ)
+			if err == nil || !strings.Contains(err.Error(), "exceeds MaxCmdBinaryValueLen (30)") {
+				t.Errorf("checkValueLen: expected oversized %v value rejected, got: %v", tt.typ, err)
+			}
+		})
+	}
+
+	// MaxCmdBinaryValueLen doesn't apply to strings
+	newLimitsService(1, 10, MaxCmdValueLenReject)
+	svc.c.Device.MaxCmdBinaryValueLen = 1000
+	if err := checkValueLen(&models.Reading{Name: "Level", Value: "123456"}, String); err == nil {
+		t.Errorf("checkValueLen: oversized String value accepted within MaxCmdBinaryValueLen")
+	}
+}
+
+func TestSplitEvent(t *testing.T) {
+	event := &models.Event{Device: "dev", Origin: 42, Readings: make([]models.Reading, 5)}
+
+	events := splitEvent(event, 0)
+	if len(events) != 1 || events[0] != event {
+		t.Errorf("splitEvent: event split without a limit")
+	}
+
+	events = splitEvent(event, 2)
+	if len(events) != 3 {
+		t.Fatalf("splitEvent: expected 3 events, got: %d", len(events))
+	}
+
+	var n int
+	for _, e := range events {
+		if e.Device != "dev" || e.Origin != 42 || len(e.Readings) > 2 {
+			t.Errorf("splitEvent: wrong event: %v", e)
+		}
+		n += len(e.Readings)
+	}
+
+	if n != 5 {
+		t.Errorf("splitEvent: expected 5 readings, got: %d", n)
+	}
+}
@@ -0,0 +1,138 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+// mapValue applies the Mappings of the given ResourceOperation to a raw
+// value read from a device, returning the display value. Values with no
+// mapping are returned unchanged.
+func mapValue(ro *models.ResourceOperation, value string) string {
+	if ro == nil || len(ro.Mappings) == 0 {
+		return value
+	}
+
+	if mapped, ok := ro.Mappings[value]; ok {
+		return mapped
+	}
+
+	return value
+}
+
+// unmapValue applies the Mappings of the given ResourceOperation in
+// reverse, returning the raw device value for a display value. An
+// error is returned if the value isn't mapped, or if several raw values
+// map to it.
+func unmapValue(ro *models.ResourceOperation, value string) (string, error) {
+	if len(ro.Mappings) == 0 {
+		return value, nil
+	}
+
+	var raws []string
+	for raw, mapped := range ro.Mappings {
+		if mapped == value {
+			raws = append(raws, raw)
+		}
+	}
+
+	switch len(raws) {
+	case 0:
+		return "", fmt.Errorf("value: %s for parameter: %s is not one of the mapped values", value, ro.Parameter)
+	case 1:
+		return raws[0], nil
+	}
+
+	sort.Strings(raws)
+	return "", fmt.Errorf("value: %s for parameter: %s is mapped from several values: %v", value, ro.Parameter, raws)
+}
+
+// duplicateMappings returns, in order, the display values which several
+// raw values map to in the given Mappings, as these can't be unmapped.
+func duplicateMappings(mappings map[string]string) []string {
+	counts := make(map[string]int)
+	for _, mapped := range mappings {
+		counts[mapped]++
+	}
+
+	var dups []string
+	for mapped, n := range counts {
+		if n > 1 {
+			dups = append(dups, mapped)
+		}
+	}
+
+	sort.Strings(dups)
+	return dups
+}
+
+// unmapParams applies reverse Mappings to the JSON encoded parameters of
+// a PUT command, which are keyed by ResourceOperation parameter name. If
+// none of the given operations have Mappings, params is returned as-is.
+func unmapParams(ops []models.ResourceOperation, params string) (string, error) {
+	var mapped bool
+	for _, op := range ops {
+		if len(op.Mappings) > 0 {
+			mapped = true
+			break
+		}
+	}
+
+	if !mapped {
+		return params, nil
+	}
+
+	var values map[string]interface{}
+	dec := json.NewDecoder(bytes.NewBufferString(params))
+	dec.UseNumber()
+
+	err := dec.Decode(&values)
+	if err != nil {
+		return "", fmt.Errorf("invalid parameters; expected JSON object: %v", err)
+	}
+
+	for i := range ops {
+		op := &ops[i]
+
+		v, ok := values[op.Parameter]
+		if !ok || len(op.Mappings) == 0 {
+			continue
+		}
+
+		var value string
+		switch v := v.(type) {
+		case string:
+			value = v
+		case json.Number:
+			value = v.String()
+		case bool:
+			value = fmt.Sprintf("%v", v)
+		default:
+			return "", fmt.Errorf("value for parameter: %s must be a string, number or bool", op.Parameter)
+		}
+
+		raw, err := unmapValue(op, value)
+		if err != nil {
+			return "", err
+		}
+
+		values[op.Parameter] = raw
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
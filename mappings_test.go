@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+var switchOp = models.ResourceOperation{
+	Operation: "set",
+	Object:    "Switch",
+	Parameter: "Switch",
+	Mappings:  map[string]string{"0": "OFF", "1": "ON"},
+}
+
+func TestMapValue(t *testing.T) {
+	if v := mapValue(&switchOp, "1"); v != "ON" {
+		t.Errorf("mapValue: expected ON, got: %s", v)
+	}
+
+	if v := mapValue(&switchOp, "2"); v != "2" {
+		t.Errorf("mapValue: unmapped value changed to: %s", v)
+	}
+
+	if v := mapValue(nil, "1"); v != "1" {
+		t.Errorf("mapValue: nil RO changed value to: %s", v)
+	}
+}
+
+func TestUnmapParams(t *testing.T) {
+	levelOp := models.ResourceOperation{Operation: "set", Object: "Level", Parameter: "Level"}
+	ops := []models.ResourceOperation{switchOp, levelOp}
+
+	params, err := unmapParams(ops, `{"Switch": "ON", "Level": 42}`)
+	if err != nil {
+		t.Fatalf("unmapParams: unexpected error: %v", err)
+	}
+
+	var values map[string]interface{}
+	err = json.Unmarshal([]byte(params), &values)
+	if err != nil {
+		t.Fatalf("unmapParams: invalid JSON returned: %s", params)
+	}
+
+	if values["Switch"] != "1" || values["Level"] != float64(42) {
+		t.Errorf("unmapParams: wrong params returned: %s", params)
+	}
+
+	_, err = unmapParams(ops, `{"Switch": "DIM"}`)
+	if err == nil {
+		t.Errorf("unmapParams: unmapped value accepted")
+	}
+
+	_, err = unmapParams(ops, `{"Switch": 1}`)
+	if err == nil {
+		t.Errorf("unmapParams: raw value accepted for mapped parameter")
+	}
+
+	dupOp := models.ResourceOperation{Operation: "set", Object: "Switch", Parameter: "Switch",
+		Mappings: map[string]string{"0": "OFF", "1": "ON", "2": "ON"}}
+	for i := 0; i < 10; i++ {
+		_, err = unmapParams([]models.ResourceOperation{dupOp}, `{"Switch": "ON"}`)
+		if err == nil {
+			t.Fatalf("unmapParams: ambiguous mapped value accepted")
+		}
+	}
+
+	_, err = unmapParams(ops, `Switch=ON`)
+	if err == nil {
+		t.Errorf("unmapParams: non-JSON params accepted")
+	}
+
+	// params are passed through untouched if there are no mappings
+	params, err = unmapParams([]models.ResourceOperation{levelOp}, `Level=42`)
+	if err != nil || params != `Level=42` {
+		t.Errorf("unmapParams: params without mappings changed to: %s; err: %v", params, err)
+	}
+}
@@ -18,7 +18,7 @@ func (dc *DeviceClientMock) Add(dev *models.Device) (string, error) {
 }
 
 func (dc *DeviceClientMock) Delete(id string) error {
-	panic("implement me")
+	return nil
 }
 
 func (dc *DeviceClientMock) DeleteByName(name string) error {
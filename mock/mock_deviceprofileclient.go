@@ -0,0 +1,95 @@
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import (
+	"net/http"
+
+	"github.com/edgexfoundry/edgex-go/pkg/clients/types"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// DeviceProfileClientMock is an in-memory DeviceProfileClient; profiles
+// added or updated through it can be read back by name.
+type DeviceProfileClientMock struct {
+	Profiles map[string]models.DeviceProfile
+}
+
+func (dpc *DeviceProfileClientMock) Add(dp *models.DeviceProfile) (string, error) {
+	if dpc.Profiles == nil {
+		dpc.Profiles = make(map[string]models.DeviceProfile)
+	}
+
+	dp.Id = bson.NewObjectId()
+	dpc.Profiles[dp.Name] = *dp
+	return dp.Id.Hex(), nil
+}
+
+func (dpc *DeviceProfileClientMock) Delete(id string) error {
+	for name, dp := range dpc.Profiles {
+		if dp.Id.Hex() == id {
+			delete(dpc.Profiles, name)
+			return nil
+		}
+	}
+
+	return types.NewErrServiceClient(http.StatusNotFound, []byte("Item not found"))
+}
+
+func (dpc *DeviceProfileClientMock) DeleteByName(name string) error {
+	if _, ok := dpc.Profiles[name]; !ok {
+		return types.NewErrServiceClient(http.StatusNotFound, []byte("Item not found"))
+	}
+
+	delete(dpc.Profiles, name)
+	return nil
+}
+
+func (dpc *DeviceProfileClientMock) DeviceProfile(id string) (models.DeviceProfile, error) {
+	for _, dp := range dpc.Profiles {
+		if dp.Id.Hex() == id {
+			return dp, nil
+		}
+	}
+
+	return models.DeviceProfile{}, types.NewErrServiceClient(http.StatusNotFound, []byte("Item not found"))
+}
+
+func (dpc *DeviceProfileClientMock) DeviceProfiles() ([]models.DeviceProfile, error) {
+	profiles := make([]models.DeviceProfile, 0, len(dpc.Profiles))
+	for _, dp := range dpc.Profiles {
+		profiles = append(profiles, dp)
+	}
+
+	return profiles, nil
+}
+
+func (dpc *DeviceProfileClientMock) DeviceProfileForName(name string) (models.DeviceProfile, error) {
+	dp, ok := dpc.Profiles[name]
+	if !ok {
+		return dp, types.NewErrServiceClient(http.StatusNotFound, []byte("Item not found"))
+	}
+
+	return dp, nil
+}
+
+func (dpc *DeviceProfileClientMock) Update(dp models.DeviceProfile) error {
+	if _, ok := dpc.Profiles[dp.Name]; !ok {
+		return types.NewErrServiceClient(http.StatusNotFound, []byte("Item not found"))
+	}
+
+	dpc.Profiles[dp.Name] = dp
+	return nil
+}
+
+func (dpc *DeviceProfileClientMock) Upload(yamlString string) (string, error) {
+	panic("implement me")
+}
+
+func (dpc *DeviceProfileClientMock) UploadFile(yamlFilePath string) (string, error) {
+	panic("implement me")
+}
@@ -0,0 +1,209 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	errDeviceRemoving = errors.New("device is being removed")
+	errDeviceBusy     = errors.New("device has operations in progress")
+)
+
+// deviceOps tracks the operations in progress for a single device.
+type deviceOps struct {
+	active   int
+	removing bool
+	// idle is closed when the last active operation completes
+	idle chan struct{}
+	// exclusive serializes operations if SerializeCommands is set; it's
+	// a semaphore rather than a mutex so that waiting can be abandoned
+	exclusive chan struct{}
+	// writes serializes read-modify-write operations
+	writes sync.Mutex
+}
+
+// operationTracker tracks in-flight operations for each device, so that
+// a device isn't removed while a ProtocolDriver is handling a command for
+// it.
+type operationTracker struct {
+	mutex   sync.Mutex
+	devices map[string]*deviceOps
+}
+
+var ot = newOperationTracker()
+
+func newOperationTracker() *operationTracker {
+	return &operationTracker{devices: make(map[string]*deviceOps)}
+}
+
+func (t *operationTracker) device(name string) *deviceOps {
+	d, ok := t.devices[name]
+	if !ok {
+		d = &deviceOps{exclusive: make(chan struct{}, 1)}
+		t.devices[name] = d
+	}
+
+	return d
+}
+
+// operation is an operation on a device started by begin. It lasts until
+// end has been called, and every ProtocolDriver call made for it has
+// returned, as a driver may still be running after the command deadline.
+type operation struct {
+	mutex sync.Mutex
+	refs  int
+	done  func()
+}
+
+// hold extends the operation until a matching call to end.
+func (op *operation) hold() {
+	op.mutex.Lock()
+	op.refs++
+	op.mutex.Unlock()
+}
+
+// end ends the operation, or a hold on it. The device is released once
+// the operation and all holds have ended.
+func (op *operation) end() {
+	op.mutex.Lock()
+	op.refs--
+	last := op.refs == 0
+	op.mutex.Unlock()
+
+	if last {
+		op.done()
+	}
+}
+
+type operationKey struct{}
+
+// withOperation returns a context derived from ctx which carries the
+// given operation, so that ProtocolDriver calls made for it can hold it.
+func withOperation(ctx context.Context, op *operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// operationOf returns the operation carried by ctx, if any.
+func operationOf(ctx context.Context) *operation {
+	op, _ := ctx.Value(operationKey{}).(*operation)
+	return op
+}
+
+// begin marks the start of an operation on the named device, and returns
+// the operation, which must be ended when it has completed. If
+// SerializeCommands is set, begin blocks until any other operation on the
+// device has completed, or ctx is done, in which case ctx.Err() is
+// returned. An error is also returned if the device is being removed.
+func (t *operationTracker) begin(ctx context.Context, name string) (*operation, error) {
+	t.mutex.Lock()
+	d := t.device(name)
+	if d.removing {
+		t.mutex.Unlock()
+		return nil, errDeviceRemoving
+	}
+
+	d.active++
+	if d.active == 1 {
+		d.idle = make(chan struct{})
+	}
+	t.mutex.Unlock()
+
+	release := func() {
+		t.mutex.Lock()
+		d.active--
+		if d.active == 0 {
+			close(d.idle)
+		}
+		t.mutex.Unlock()
+	}
+
+	exclusive := svc.c.Device.SerializeCommands
+	if exclusive {
+		select {
+		case d.exclusive <- struct{}{}:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	return &operation{refs: 1, done: func() {
+		if exclusive {
+			<-d.exclusive
+		}
+		release()
+	}}, nil
+}
+
+// lockWrites serializes read-modify-write operations on the named device,
+// which must be within an operation started by begin. It returns a
+// function which must be called when the write has completed.
+func (t *operationTracker) lockWrites(name string) func() {
+	t.mutex.Lock()
+	d := t.device(name)
+	t.mutex.Unlock()
+
+	d.writes.Lock()
+	return d.writes.Unlock
+}
+
+// beginRemove marks the named device as being removed, which causes any
+// new operations to be refused, and waits up to timeout for operations in
+// progress to complete. If they don't, the device is unmarked and
+// errDeviceBusy is returned. Otherwise endRemove must be called once the
+// device has been removed.
+func (t *operationTracker) beginRemove(name string, timeout time.Duration) error {
+	t.mutex.Lock()
+	d := t.device(name)
+	if d.removing {
+		t.mutex.Unlock()
+		return errDeviceRemoving
+	}
+
+	d.removing = true
+	active, idle := d.active, d.idle
+	t.mutex.Unlock()
+
+	if active == 0 {
+		return nil
+	}
+
+	select {
+	case <-idle:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// the last operation may have completed since the timeout fired
+	if d.active == 0 {
+		return nil
+	}
+
+	d.removing = false
+	return errDeviceBusy
+}
+
+// endRemove discards the tracking state of the named device.
+func (t *operationTracker) endRemove(name string) {
+	t.mutex.Lock()
+	delete(t.devices, name)
+	t.mutex.Unlock()
+}
+
+// removeWaitTimeout returns the time a device removal waits for
+// operations in progress to complete.
+func removeWaitTimeout() time.Duration {
+	return time.Duration(svc.c.Device.RemoveWaitTimeout) * time.Millisecond
+}
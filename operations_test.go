@@ -0,0 +1,88 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOperationTrackerRemove(t *testing.T) {
+	svc = &Service{c: &Config{}}
+	tracker := newOperationTracker()
+
+	op, err := tracker.begin(context.Background(), "dev")
+	if err != nil {
+		t.Fatalf("begin: unexpected error: %v", err)
+	}
+
+	// removal is refused while a command is in progress
+	err = tracker.beginRemove("dev", 10*time.Millisecond)
+	if err != errDeviceBusy {
+		t.Errorf("beginRemove: expected errDeviceBusy, got: %v", err)
+	}
+
+	// removal waits for the command to complete
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		op.end()
+	}()
+
+	err = tracker.beginRemove("dev", time.Second)
+	if err != nil {
+		t.Fatalf("beginRemove: unexpected error: %v", err)
+	}
+
+	// new commands are refused while the device is being removed
+	_, err = tracker.begin(context.Background(), "dev")
+	if err != errDeviceRemoving {
+		t.Errorf("begin: expected errDeviceRemoving, got: %v", err)
+	}
+
+	tracker.endRemove("dev")
+
+	op, err = tracker.begin(context.Background(), "dev")
+	if err != nil {
+		t.Errorf("begin: unexpected error after removal: %v", err)
+	} else {
+		op.end()
+	}
+}
+
+func TestOperationTrackerSerialize(t *testing.T) {
+	config := Config{}
+	config.Device.SerializeCommands = true
+	svc = &Service{c: &config}
+	tracker := newOperationTracker()
+
+	op, err := tracker.begin(context.Background(), "dev")
+	if err != nil {
+		t.Fatalf("begin: unexpected error: %v", err)
+	}
+
+	started := make(chan struct{})
+	go func() {
+		op, _ := tracker.begin(context.Background(), "dev")
+		close(started)
+		op.end()
+	}()
+
+	select {
+	case <-started:
+		t.Fatalf("begin: concurrent command started on serialized device")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	op.end()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Errorf("begin: serialized command not started after release")
+	}
+}
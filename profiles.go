@@ -9,13 +9,14 @@ package device
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/edgexfoundry/edgex-go/pkg/models"
 	"gopkg.in/mgo.v2/bson"
-	"gopkg.in/yaml.v2"
 )
 
 const (
@@ -24,12 +25,20 @@ const (
 	v1Schedule        = "/api/v1/schedule"
 	v1ScheduleEvent   = "/api/v1/scheduleevent"
 	yamlExt           = ".yaml"
-	yamlExtUpper      = ".YAML"
+	ymlExt            = ".yml"
+	jsonExt           = ".json"
+
+	// maxResourceChainDepth is the maximum number of nested resources
+	// which are followed when resolving resource command chaining.
+	maxResourceChainDepth = 8
 )
 
 // profileCache is a local cache of devices seeded from Core Metadata.
 type profileCache struct {
 	config *Config
+	// mutex guards the maps below, which may be updated by the
+	// profileWatcher while commands are being executed.
+	mutex sync.RWMutex
 	// TODO: descriptors should be a map of vds.name to vds!!!
 	descriptors []models.ValueDescriptor
 	commands    map[string]map[string]map[string][]models.ResourceOperation
@@ -52,12 +61,28 @@ func findProfile(name string, profiles []models.DeviceProfile) (found bool) {
 	return
 }
 
-func loadProfiles(path string) {
+// profilesDir returns the absolute path of the given ProfilesDir setting.
+func profilesDir(path string) (string, error) {
 	if path == "" {
 		path = "./res"
 	}
 
-	absPath, err := filepath.Abs(path)
+	return filepath.Abs(path)
+}
+
+// isProfileFile returns whether the named file in the
+// ProfilesDir should be loaded as a deviceprofile.
+func isProfileFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case yamlExt, ymlExt, jsonExt:
+		return true
+	}
+
+	return false
+}
+
+func loadProfiles(path string) {
+	absPath, err := profilesDir(path)
 	if err != nil {
 		svc.lc.Error(fmt.Sprintf("profiles: couldn't create absolute path for: %s; %v\n", path, err))
 		return
@@ -75,22 +100,21 @@ func loadProfiles(path string) {
 		return
 	}
 
-	for _, file := range fileInfo {
-		var profile models.DeviceProfile
+	var errs ProfileErrors
 
+	for _, file := range fileInfo {
 		name := file.Name()
-		if strings.HasSuffix(name, yamlExt) || strings.HasSuffix(name, yamlExtUpper) {
-			path := absPath + "/" + name
-			yamlFile, err := ioutil.ReadFile(path)
+		if !isProfileFile(name) {
+			continue
+		}
 
-			if err != nil {
-				svc.lc.Error(fmt.Sprintf("profiles: couldn't read file: %s; %v\n", name, err))
-			}
+		valid, err := ValidateProfileFile(absPath + "/" + name)
+		if err != nil {
+			errs = append(errs, err.(ProfileErrors)...)
+		}
 
-			err = yaml.Unmarshal(yamlFile, &profile)
-			if err != nil {
-				svc.lc.Error(fmt.Sprintf("profiles: invalid deviceprofile: %s; %v\n", name, err))
-			}
+		for i := range valid {
+			profile := &valid[i]
 
 			// if profile already exists in metadata, skip it
 			// TODO: optimize by making profiles a map
@@ -98,22 +122,36 @@ func loadProfiles(path string) {
 				continue
 			}
 
-			// add profile to metadata
-			id, err := svc.dpc.Add(&profile)
+			err = addProfile(profile)
 			if err != nil {
-				svc.lc.Error(fmt.Sprintf("profiles: Add device profile: %s to Core Metadata failed: %v\n", name, err))
-				continue
+				svc.lc.Error(fmt.Sprintf("profiles: Add device profile: %s from: %s to Core Metadata failed: %v\n", profile.Name, name, err))
 			}
+		}
+	}
 
-			if len(id) != 24 || !bson.IsObjectIdHex(id) {
-				svc.lc.Error("Add deviceprofile returned invalid Id: " + id)
-				return
-			}
+	if len(errs) > 0 {
+		svc.lc.Error(fmt.Sprintf("profiles: invalid deviceprofiles in: %s skipped; %d problem(s) found:\n%v\n", absPath, len(errs), errs))
+	}
+}
 
-			profile.Id = bson.ObjectIdHex(id)
-			pc.profiles[profile.Name] = profile
-		}
+// addProfile adds the given profile to Core Metadata and the local cache.
+func addProfile(profile *models.DeviceProfile) error {
+	id, err := svc.dpc.Add(profile)
+	if err != nil {
+		return err
 	}
+
+	if len(id) != 24 || !bson.IsObjectIdHex(id) {
+		return fmt.Errorf("Add deviceprofile returned invalid Id: %s", id)
+	}
+
+	profile.Id = bson.ObjectIdHex(id)
+
+	pc.mutex.Lock()
+	pc.profiles[profile.Name] = *profile
+	pc.mutex.Unlock()
+
+	return nil
 }
 
 // Create a singleton profileCache cache instance. The cache
@@ -136,6 +174,9 @@ func newProfileCache() {
 func (p *profileCache) descriptorExists(name string) bool {
 	var exists bool
 
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
 	// TODO: make this a map?
 	for _, desc := range p.descriptors {
 		if desc.Name == name {
@@ -149,6 +190,9 @@ func (p *profileCache) descriptorExists(name string) bool {
 
 // getDeviceObjects returns a map of object names to DeviceObject instances.
 func (p *profileCache) getDeviceObjects(devName string) map[string]models.DeviceObject {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
 	devObjs := p.objects[devName]
 	return devObjs
 }
@@ -179,12 +223,58 @@ func (p *profileCache) getDeviceObject(d *models.Device, op *models.ResourceOper
 	return pc.getDeviceObjectByName(d.Name, op)
 }
 
+// getReadingObjects returns the DeviceObjects of the named device keyed by
+// the names of their readings. As per getDeviceObjectByName, a reading is
+// named after its ResourceOperation's parameter if a value descriptor of
+// that name exists, otherwise after its DeviceObject.
+func (p *profileCache) getReadingObjects(devName string) map[string]models.DeviceObject {
+	p.mutex.RLock()
+	devObjs := p.objects[devName]
+	cmds := p.commands[devName]
+
+	// visit commands in order, so that a parameter shared by several
+	// DeviceObjects resolves consistently
+	names := make([]string, 0, len(cmds))
+	for name := range cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ops []models.ResourceOperation
+	for _, name := range names {
+		ops = append(ops, cmds[name]["get"]...)
+		ops = append(ops, cmds[name]["set"]...)
+	}
+	p.mutex.RUnlock()
+
+	readingObjs := make(map[string]models.DeviceObject, len(devObjs))
+	for name, devObj := range devObjs {
+		readingObjs[name] = devObj
+	}
+
+	for _, op := range ops {
+		if _, ok := readingObjs[op.Parameter]; ok || op.Parameter == "" {
+			continue
+		}
+
+		devObj, ok := devObjs[op.Object]
+		if ok && p.descriptorExists(op.Parameter) {
+			readingObjs[op.Parameter] = devObj
+		}
+	}
+
+	return readingObjs
+}
+
 // CommandExists returns a bool indicating whether the specified command exists for the
 // specified (by name) device. If the specified device doesn't exist, an error is returned.
 // Note - this command currently checks that a deviceprofile *resource* with the given name
 // exists, it's not actually checking that a deviceprofile *command* with this name exists.
 // See addDevice() for more details.
 func (p *profileCache) CommandExists(devName string, cmd string) (bool, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
 	devOps, ok := p.commands[devName]
 	if !ok {
 		err := fmt.Errorf("profiles: CommandExists: specified dev: %s not found", devName)
@@ -198,10 +288,36 @@ func (p *profileCache) CommandExists(devName string, cmd string) (bool, error) {
 	return true, nil
 }
 
+// commandMethods returns the commands of the named device, keyed by lower
+// case name, with the HTTP methods supported by each.
+func (p *profileCache) commandMethods(devName string) map[string][]string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	methods := make(map[string][]string)
+	for name, ops := range p.commands[devName] {
+		m := []string{}
+		if len(ops["get"]) > 0 {
+			m = append(m, http.MethodGet)
+		}
+
+		if len(ops["set"]) > 0 {
+			m = append(m, http.MethodPut)
+		}
+
+		methods[name] = m
+	}
+
+	return methods
+}
+
 // GetResourceOperation...
 func (p *profileCache) GetResourceOperations(devName string, cmd string, method string) ([]models.ResourceOperation, error) {
 	var err error
 
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
 	devOps, ok := p.commands[devName]
 	if !ok {
 		err = fmt.Errorf("profiles: GetResourceOperations: specified dev: %s not found", devName)
@@ -214,7 +330,13 @@ func (p *profileCache) GetResourceOperations(devName string, cmd string, method
 		return nil, err
 	}
 
-	resOps, ok := cmdOps[strings.ToLower(method)]
+	// set operations are used for PUT commands
+	opType := strings.ToLower(method)
+	if opType == "put" {
+		opType = "set"
+	}
+
+	resOps, ok := cmdOps[opType]
 	if !ok {
 		err = fmt.Errorf("profiles: GetResourceOperations: specified cmd method: %s not found", method)
 		return nil, err
@@ -223,6 +345,74 @@ func (p *profileCache) GetResourceOperations(devName string, cmd string, method
 	return resOps, nil
 }
 
+// resolveResourceChains resolves resource command chaining in the given
+// ResourceOperations of a device command. An operation chains to another
+// resource when its Resource field is set, or when its Object names a
+// resource instead of a device resource (see the BoschXDK profile for
+// reference). Chained operations are replaced by the operations of the
+// referenced resource, recursively, resulting in a flat list of operations
+// on device resources. An error is returned if a chain contains a cycle,
+// or exceeds maxResourceChainDepth.
+func (p *profileCache) resolveResourceChains(devName string, cmd string, method string,
+	ops []models.ResourceOperation) ([]models.ResourceOperation, error) {
+
+	devObjs := p.getDeviceObjects(devName)
+	chain := []string{strings.ToLower(cmd)}
+
+	return p.resolveChain(devName, method, ops, devObjs, chain)
+}
+
+func (p *profileCache) resolveChain(devName string, method string, ops []models.ResourceOperation,
+	devObjs map[string]models.DeviceObject, chain []string) ([]models.ResourceOperation, error) {
+
+	var resolved []models.ResourceOperation
+
+	for _, op := range ops {
+		res := op.Resource
+		if res == "" {
+			if _, ok := devObjs[op.Object]; ok {
+				resolved = append(resolved, op)
+				continue
+			}
+
+			// not a device resource, so check for a resource of the same name
+			res = op.Object
+			if exists, _ := p.CommandExists(devName, res); !exists {
+				resolved = append(resolved, op)
+				continue
+			}
+		}
+
+		name := strings.ToLower(res)
+		for _, prev := range chain {
+			if prev == name {
+				return nil, fmt.Errorf("profiles: resource command chain cycle for dev: %s; %s -> %s",
+					devName, strings.Join(chain, " -> "), name)
+			}
+		}
+
+		if len(chain) > maxResourceChainDepth {
+			return nil, fmt.Errorf("profiles: resource command chain for dev: %s exceeds max depth (%d); %s -> %s",
+				devName, maxResourceChainDepth, strings.Join(chain, " -> "), name)
+		}
+
+		chainedOps, err := p.GetResourceOperations(devName, res, method)
+		if err != nil {
+			return nil, fmt.Errorf("profiles: resource command chain for dev: %s; %s -> %s: %v",
+				devName, strings.Join(chain, " -> "), name, err)
+		}
+
+		chainedOps, err = p.resolveChain(devName, method, chainedOps, devObjs, append(chain, name))
+		if err != nil {
+			return nil, err
+		}
+
+		resolved = append(resolved, chainedOps...)
+	}
+
+	return resolved, nil
+}
+
 // TODO: this function is based on the original Java device-sdk-tools,
 // and is too large & complicated; re-factor for simplicity, testability!
 func (p *profileCache) addDevice(d *models.Device) error {
@@ -378,8 +568,10 @@ func (p *profileCache) addDevice(d *models.Device) error {
 	svc.lc.Debug(fmt.Sprintf("profiles: ops: %v\n\n", ops))
 	svc.lc.Debug(fmt.Sprintf("\n\nprofiles: deviceOps: %v\n\n", devOps))
 
+	p.mutex.Lock()
 	p.objects[d.Name] = devObjs
 	p.commands[d.Name] = devOps
+	p.mutex.Unlock()
 
 	// Create a value descriptor for each parameter using its underlying object
 	for _, op := range ops {
@@ -419,6 +611,11 @@ func (p *profileCache) addDevice(d *models.Device) error {
 				}
 			}
 
+			// operations which chain to another resource have no object
+			if devObj == nil {
+				continue
+			}
+
 			desc = p.createDescriptor(op.Parameter, *devObj)
 			if desc == nil {
 				// TODO: should the whole thing unwind due to this failure?
@@ -426,26 +623,78 @@ func (p *profileCache) addDevice(d *models.Device) error {
 			}
 		}
 
+		p.mutex.Lock()
 		p.descriptors = append(p.descriptors, *desc)
+		p.mutex.Unlock()
 		descs = append(descs, *desc)
 	}
 
+	// Create a value descriptor for each aggregate and quality reading
+	for _, dr := range d.Profile.DeviceResources {
+		derived := make(map[string]models.DeviceObject)
+
+		agg, ok, _ := aggregationOf(&dr)
+		if ok {
+			for _, op := range agg.ops {
+				derived[aggregateName(dr.Name, op)] = dr
+			}
+		}
+
+		if policy, _ := validationOf(&dr, svc.c.Device.ResultValidation); policy == ValidationMark {
+			qdr := dr
+			qdr.Properties.Value = models.PropertyValue{Type: "String", ReadWrite: "R"}
+			qdr.Properties.Units = models.Units{}
+			qdr.Attributes = nil
+			derived[qualityName(dr.Name)] = qdr
+		}
+
+		for name, dr := range derived {
+			if p.descriptorExists(name) {
+				continue
+			}
+
+			var desc *models.ValueDescriptor
+			for i := range descs {
+				if descs[i].Name == name {
+					desc = &descs[i]
+					break
+				}
+			}
+
+			if desc == nil {
+				desc = p.createDescriptor(name, dr)
+				if desc == nil {
+					continue
+				}
+			}
+
+			p.mutex.Lock()
+			p.descriptors = append(p.descriptors, *desc)
+			p.mutex.Unlock()
+		}
+	}
+
 	return nil
 }
 
-func (p *profileCache) updateDevice(d *models.Device) {
-	p.removeDevice(d)
-	p.addDevice(d)
+// updateDevice re-creates the resources and commands of the given device.
+// addDevice replaces the device's entries under the cache lock, so
+// commands see either the old or the new entries, and the old entries
+// are kept if the device can't be added.
+func (p *profileCache) updateDevice(d *models.Device) error {
+	return p.addDevice(d)
 }
 
 func (p *profileCache) removeDevice(d *models.Device) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	delete(p.objects, d.Name)
 	delete(p.commands, d.Name)
 }
 
 func (p *profileCache) createDescriptor(name string, devObj models.DeviceObject) *models.ValueDescriptor {
-	value := devObj.Properties.Value
-	units := devObj.Properties.Units
+	value, units := publishedProperties(&devObj)
 
 	svc.lc.Debug(fmt.Sprintf("ps: createDescriptor: %v value: %v units: %s\n", name, value, units))
 
@@ -454,7 +703,7 @@ func (p *profileCache) createDescriptor(name string, devObj models.DeviceObject)
 		Min:          value.Minimum,
 		Max:          value.Maximum,
 		Type:         value.Type,
-		UomLabel:     units.DefaultValue,
+		UomLabel:     units,
 		DefaultValue: value.DefaultValue,
 		Formatting:   "%s",
 		Description:  devObj.Description,
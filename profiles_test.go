@@ -0,0 +1,140 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+const chainDevice = "chain-device"
+
+func getOp(object string) models.ResourceOperation {
+	return models.ResourceOperation{Operation: "get", Object: object, Parameter: object}
+}
+
+func newChainCache(resources map[string][]models.ResourceOperation) *profileCache {
+	p := &profileCache{
+		objects:  make(map[string]map[string]models.DeviceObject),
+		commands: make(map[string]map[string]map[string][]models.ResourceOperation),
+	}
+
+	p.objects[chainDevice] = map[string]models.DeviceObject{
+		"temperature": {Name: "temperature"},
+		"humidity":    {Name: "humidity"},
+		"pressure":    {Name: "pressure"},
+	}
+
+	devOps := make(map[string]map[string][]models.ResourceOperation)
+	for name := range p.objects[chainDevice] {
+		devOps[name] = map[string][]models.ResourceOperation{"get": {getOp(name)}}
+	}
+
+	for name, ops := range resources {
+		devOps[strings.ToLower(name)] = map[string][]models.ResourceOperation{"get": ops}
+	}
+
+	p.commands[chainDevice] = devOps
+	return p
+}
+
+func resolveOps(p *profileCache, cmd string) ([]models.ResourceOperation, error) {
+	ops, err := p.GetResourceOperations(chainDevice, cmd, "get")
+	if err != nil {
+		return nil, err
+	}
+
+	return p.resolveResourceChains(chainDevice, cmd, "get", ops)
+}
+
+func TestResolveResourceChains(t *testing.T) {
+	p := newChainCache(map[string][]models.ResourceOperation{
+		"Climate": {getOp("temperature"), getOp("humidity")},
+		// chains via Object naming a resource
+		"Environment": {getOp("Climate"), getOp("pressure")},
+		// chains via the Resource field
+		"All": {{Operation: "get", Resource: "Environment"}, getOp("temperature")},
+	})
+
+	var tests = []struct {
+		cmd     string
+		objects []string
+	}{
+		{"temperature", []string{"temperature"}},
+		{"Climate", []string{"temperature", "humidity"}},
+		{"Environment", []string{"temperature", "humidity", "pressure"}},
+		{"All", []string{"temperature", "humidity", "pressure", "temperature"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			ops, err := resolveOps(p, tt.cmd)
+			if err != nil {
+				t.Fatalf("resolveResourceChains: unexpected error: %v", err)
+			}
+
+			objects := make([]string, len(ops))
+			for i, op := range ops {
+				objects[i] = op.Object
+			}
+
+			if !compareStrings(objects, tt.objects) {
+				t.Errorf("resolveResourceChains: expected objects: %v got: %v", tt.objects, objects)
+			}
+		})
+	}
+}
+
+func TestResolveResourceChainsCycle(t *testing.T) {
+	p := newChainCache(map[string][]models.ResourceOperation{
+		"A": {getOp("temperature"), getOp("B")},
+		"B": {{Operation: "get", Resource: "C"}},
+		"C": {getOp("A")},
+	})
+
+	_, err := resolveOps(p, "A")
+	if err == nil {
+		t.Fatal("resolveResourceChains: cycle not detected")
+	}
+
+	if !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("resolveResourceChains: cycle not described in error: %v", err)
+	}
+}
+
+func TestResolveResourceChainsMaxDepth(t *testing.T) {
+	resources := make(map[string][]models.ResourceOperation)
+	for i := 0; i <= maxResourceChainDepth; i++ {
+		resources[fmt.Sprintf("R%d", i)] = []models.ResourceOperation{getOp(fmt.Sprintf("R%d", i+1))}
+	}
+	resources[fmt.Sprintf("R%d", maxResourceChainDepth+1)] = []models.ResourceOperation{getOp("pressure")}
+
+	_, err := resolveOps(newChainCache(resources), "R0")
+	if err == nil || !strings.Contains(err.Error(), "max depth") {
+		t.Errorf("resolveResourceChains: expected max depth error, got: %v", err)
+	}
+
+	// a chain of exactly the max depth is resolved
+	ops, err := resolveOps(newChainCache(resources), "R1")
+	if err != nil || len(ops) != 1 || ops[0].Object != "pressure" {
+		t.Errorf("resolveResourceChains: max depth chain; ops: %v err: %v", ops, err)
+	}
+}
+
+func TestResolveResourceChainsUnknownResource(t *testing.T) {
+	p := newChainCache(map[string][]models.ResourceOperation{
+		"Broken": {{Operation: "get", Resource: "Missing"}},
+	})
+
+	_, err := resolveOps(p, "Broken")
+	if err == nil || !strings.Contains(err.Error(), "broken -> missing") {
+		t.Errorf("resolveResourceChains: expected error for unknown resource, got: %v", err)
+	}
+}
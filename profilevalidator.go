@@ -0,0 +1,541 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+	"gopkg.in/yaml.v2"
+)
+
+// propertyTypes maps the (lower-case) PropertyValue types accepted in a
+// deviceprofile to the ResultType used to validate their values. The legacy
+// Java type names are accepted for compatibility with existing profiles.
+var propertyTypes = map[string]ResultType{
+	"bool":    Bool,
+	"boolean": Bool,
+	"string":  String,
+	"uint8":   Uint8,
+	"uint16":  Uint16,
+	"uint32":  Uint32,
+	"uint64":  Uint64,
+	"int8":    Int8,
+	"int16":   Int16,
+	"int32":   Int32,
+	"int64":   Int64,
+	"integer": Int64,
+	"float32": Float32,
+	"float64": Float64,
+	"float":   Float64,
+
+	"binary":       Binary,
+	"uint8array":   Uint8Array,
+	"uint16array":  Uint16Array,
+	"uint32array":  Uint32Array,
+	"uint64array":  Uint64Array,
+	"int8array":    Int8Array,
+	"int16array":   Int16Array,
+	"int32array":   Int32Array,
+	"int64array":   Int64Array,
+	"float32array": Float32Array,
+	"float64array": Float64Array,
+	"json":         JSON,
+}
+
+// ProfileError describes a single problem found in a device profile. File
+// and Line are only set if the profile was read from a file, and the
+// location of the problem could be determined.
+type ProfileError struct {
+	File    string
+	Line    int
+	Profile string
+	Msg     string
+}
+
+// Error returns a string representation of a ProfileError in the
+// conventional file:line: format.
+func (e ProfileError) Error() string {
+	var prefix string
+
+	if e.File != "" {
+		prefix = e.File + ":"
+		if e.Line > 0 {
+			prefix += strconv.Itoa(e.Line) + ":"
+		}
+		prefix += " "
+	}
+
+	if e.Profile != "" {
+		prefix += "profile " + e.Profile + ": "
+	}
+
+	return prefix + e.Msg
+}
+
+// ProfileErrors is the complete list of problems found while validating
+// one or more device profiles.
+type ProfileErrors []ProfileError
+
+// Error returns all of the problems, one per line.
+func (pe ProfileErrors) Error() string {
+	msgs := make([]string, len(pe))
+	for i, e := range pe {
+		msgs[i] = e.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// ValidateProfile checks that the given device profile is consistent, and
+// returns a ProfileErrors value listing every problem found, or nil if the
+// profile is valid. The following checks are made:
+//
+//   - each ResourceOperation references an existing DeviceObject, or
+//     another resource if resource command chaining is used
+//   - each command maps to a resource or DeviceObject of the same name
+//   - each PropertyValue has a valid Type and ReadWrite flags
+//   - each PropertyValue Minimum and Maximum can be parsed as its Type
+//   - each DeviceObject's Attributes decode into the attribute schema
+//     registered by the ProtocolDriver, if any
+func ValidateProfile(profile models.DeviceProfile) error {
+	return validateProfile(profileSource{}, &profile).errorOrNil()
+}
+
+// ValidateProfileFile reads the deviceprofile file at path and validates
+// each profile it contains as per ValidateProfile. YAML files may contain
+// several profiles as separate documents, and JSON files may contain either
+// a single profile object or an array of profiles. The valid profiles are
+// returned along with a ProfileErrors value, which includes the file and
+// line context of each problem found in the invalid ones.
+func ValidateProfileFile(path string) ([]models.DeviceProfile, error) {
+	var valid []models.DeviceProfile
+
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, ProfileErrors{{File: path, Msg: err.Error()}}
+	}
+
+	var errs ProfileErrors
+	var sources []profileSource
+
+	if strings.ToLower(filepath.Ext(path)) == jsonExt {
+		sources, err = splitJSONProfiles(path, src)
+	} else {
+		sources = splitYAMLProfiles(path, src)
+	}
+
+	if err != nil {
+		return nil, ProfileErrors{{File: path, Line: jsonErrorLine(src, err), Msg: err.Error()}}
+	}
+
+	for _, ps := range sources {
+		profile, err := ps.unmarshal()
+		if err != nil {
+			errs = append(errs, *err)
+			continue
+		}
+
+		profileErrs := validateProfile(ps, &profile)
+		if len(profileErrs) > 0 {
+			errs = append(errs, profileErrs...)
+			continue
+		}
+
+		valid = append(valid, profile)
+	}
+
+	return valid, errs.errorOrNil()
+}
+
+func (pe ProfileErrors) errorOrNil() error {
+	if len(pe) == 0 {
+		return nil
+	}
+
+	return pe
+}
+
+// profileSource is the source of a single deviceprofile read from a file.
+type profileSource struct {
+	file string
+	// line is the line number within file where src starts.
+	line int
+	src  []byte
+	json bool
+}
+
+var yamlLineRe = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// unmarshal decodes the profile contained in ps. Any error
+// is returned with its line number relative to the file.
+func (ps profileSource) unmarshal() (models.DeviceProfile, *ProfileError) {
+	var profile models.DeviceProfile
+	var err error
+
+	if ps.json {
+		err = json.Unmarshal(ps.src, &profile)
+	} else {
+		err = yaml.Unmarshal(ps.src, &profile)
+	}
+
+	if err == nil {
+		return profile, nil
+	}
+
+	pe := &ProfileError{File: ps.file, Line: ps.line, Msg: err.Error()}
+
+	if m := yamlLineRe.FindStringSubmatch(pe.Msg); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		pe.Line = ps.line + n - 1
+		pe.Msg = "yaml: " + pe.Msg[len(m[0]):]
+	} else if ps.json {
+		if n := jsonErrorLine(ps.src, err); n > 0 {
+			pe.Line = ps.line + n - 1
+		}
+	}
+
+	return profile, pe
+}
+
+// fileLine returns the line number within the file of the given
+// line within ps, which is zero if the line isn't known.
+func (ps profileSource) fileLine(line int) int {
+	if line == 0 {
+		return 0
+	}
+
+	return ps.line + line - 1
+}
+
+// splitYAMLProfiles splits src into its YAML documents, skipping any
+// which are empty.
+func splitYAMLProfiles(file string, src []byte) []profileSource {
+	var sources []profileSource
+
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	start := 0
+
+	flush := func(end int) {
+		doc := bytes.Join(lines[start:end], nil)
+		if len(bytes.TrimSpace(doc)) > 0 {
+			sources = append(sources, profileSource{file: file, line: start + 1, src: doc})
+		}
+	}
+
+	for i, line := range lines {
+		trimmed := bytes.TrimRight(line, " \t\r\n")
+		if bytes.Equal(trimmed, []byte("---")) || bytes.Equal(trimmed, []byte("...")) {
+			flush(i)
+			start = i + 1
+		}
+	}
+	flush(len(lines))
+
+	return sources
+}
+
+// splitJSONProfiles splits src into its profiles, which is either
+// a single JSON object, or an array of objects.
+func splitJSONProfiles(file string, src []byte) ([]profileSource, error) {
+	trimmed := bytes.TrimSpace(src)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return []profileSource{{file: file, line: 1, src: src, json: true}}, nil
+	}
+
+	var raws []json.RawMessage
+	err := json.Unmarshal(src, &raws)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]profileSource, len(raws))
+	offset := 0
+	for i, raw := range raws {
+		// RawMessage holds a copy of the element's source, so
+		// find it to determine the line the element starts on
+		if n := bytes.Index(src[offset:], raw); n >= 0 {
+			offset += n
+		}
+
+		line := bytes.Count(src[:offset], []byte("\n")) + 1
+		sources[i] = profileSource{file: file, line: line, src: raw, json: true}
+	}
+
+	return sources, nil
+}
+
+// jsonErrorLine returns the line number of a JSON syntax error in src,
+// or zero if err isn't a syntax error.
+func jsonErrorLine(src []byte, err error) int {
+	serr, ok := err.(*json.SyntaxError)
+	if !ok || serr.Offset > int64(len(src)) {
+		return 0
+	}
+
+	return bytes.Count(src[:serr.Offset], []byte("\n")) + 1
+}
+
+func validateProfile(ps profileSource, profile *models.DeviceProfile) ProfileErrors {
+	var errs ProfileErrors
+	src := ps.src
+
+	report := func(line int, format string, args ...interface{}) {
+		errs = append(errs, ProfileError{
+			File:    ps.file,
+			Line:    ps.fileLine(line),
+			Profile: profile.Name,
+			Msg:     fmt.Sprintf(format, args...),
+		})
+	}
+
+	if profile.Name == "" {
+		report(0, "missing name")
+	}
+
+	if len(profile.DeviceResources) == 0 {
+		report(lineOf(src, "", "deviceResources", ""), "no device resources")
+	}
+
+	devObjs := make(map[string]bool)
+	for _, do := range profile.DeviceResources {
+		line := lineOf(src, "deviceResources", "name", do.Name)
+
+		if do.Name == "" {
+			report(line, "device resource with no name")
+			continue
+		}
+
+		if devObjs[do.Name] {
+			report(line, "duplicate device resource: %s", do.Name)
+		}
+		devObjs[do.Name] = true
+
+		for _, msg := range validatePropertyValue(do.Properties.Value) {
+			report(line, "device resource %s: %s", do.Name, msg)
+		}
+
+		if _, _, err := aggregationOf(&do); err != nil {
+			report(line, "%v", err)
+		}
+
+		if _, err := floatEncodingOf(&do); err != nil {
+			report(line, "%v", err)
+		}
+
+		if _, err := validationOf(&do, ""); err != nil {
+			report(line, "%v", err)
+		}
+
+		if _, _, err := unitConversionOf(&do); err != nil {
+			report(line, "%v", err)
+		}
+
+		// bit fields are read by the SDK, not the ProtocolDriver
+		if _, isBitField := AttributeString(do.Attributes, BitSourceAttribute); !isBitField {
+			if err := validateAttributes(do.Attributes); err != nil {
+				report(line, "device resource %s: %v", do.Name, err)
+			}
+		}
+	}
+
+	problems := validateBitFields(profile.DeviceResources)
+	for _, do := range profile.DeviceResources {
+		if msg, ok := problems[do.Name]; ok {
+			report(lineOf(src, "deviceResources", "name", do.Name), "%s", msg)
+		}
+	}
+
+	resources := make(map[string]bool)
+	for _, r := range profile.Resources {
+		resources[strings.ToLower(r.Name)] = true
+	}
+
+	for name := range devObjs {
+		resources[strings.ToLower(name)] = true
+	}
+
+	// operations may reference either a device resource, or another
+	// resource via resource command chaining
+	for _, r := range profile.Resources {
+		for _, ops := range [][]models.ResourceOperation{r.Get, r.Set} {
+			for _, ro := range ops {
+				if dups := duplicateMappings(ro.Mappings); len(dups) > 0 {
+					line := lineOf(src, "resources", "name", r.Name)
+					report(line, "resource %s: operation %s maps several values to: %s",
+						r.Name, ro.Operation, strings.Join(dups, ", "))
+				}
+
+				if ro.Resource != "" {
+					if !resources[strings.ToLower(ro.Resource)] {
+						line := lineOf(src, "resources", "resource", ro.Resource)
+						report(line, "resource %s: operation %s references unknown resource: %s",
+							r.Name, ro.Operation, ro.Resource)
+					}
+				} else if !devObjs[ro.Object] && !resources[strings.ToLower(ro.Object)] {
+					line := lineOf(src, "resources", "object", ro.Object)
+					report(line, "resource %s: operation %s references unknown device resource: %s",
+						r.Name, ro.Operation, ro.Object)
+				}
+			}
+		}
+	}
+
+	for _, cmd := range profile.Commands {
+		if !resources[strings.ToLower(cmd.Name)] {
+			line := lineOf(src, "commands", "name", cmd.Name)
+			report(line, "command %s has no matching resource or device resource", cmd.Name)
+		}
+	}
+
+	return errs
+}
+
+// validatePropertyValue returns a description of each problem
+// found with the given PropertyValue.
+func validatePropertyValue(pv models.PropertyValue) []string {
+	var msgs []string
+
+	switch strings.ToLower(pv.ReadWrite) {
+	case "r", "w", "rw":
+	default:
+		msgs = append(msgs, fmt.Sprintf("invalid readWrite: %q", pv.ReadWrite))
+	}
+
+	t, ok := propertyTypes[strings.ToLower(pv.Type)]
+	if !ok {
+		msgs = append(msgs, fmt.Sprintf("invalid type: %q", pv.Type))
+		return msgs
+	}
+
+	min, err := parseLimit(t, pv.Minimum)
+	if err != nil {
+		msgs = append(msgs, fmt.Sprintf("invalid minimum: %q; %v", pv.Minimum, err))
+	}
+
+	max, err := parseLimit(t, pv.Maximum)
+	if err != nil {
+		msgs = append(msgs, fmt.Sprintf("invalid maximum: %q; %v", pv.Maximum, err))
+	}
+
+	if min != nil && max != nil && *min > *max {
+		msgs = append(msgs, fmt.Sprintf("minimum: %s is greater than maximum: %s", pv.Minimum, pv.Maximum))
+	}
+
+	if (t == Binary || t == JSON) && hasTransform(pv) {
+		msgs = append(msgs, fmt.Sprintf("transforms not supported for type: %s", pv.Type))
+	}
+
+	tr, err := parseTransforms(pv)
+	if err != nil {
+		msgs = append(msgs, err.Error())
+	} else if elem, _ := arrayElementType(t); (elem == Float32 || elem == Float64) && (tr.hasMask || tr.hasShift) {
+		msgs = append(msgs, fmt.Sprintf("mask and shift not supported for type: %s", pv.Type))
+	}
+
+	return msgs
+}
+
+// parseLimit parses a PropertyValue Minimum or Maximum as the given type.
+// A nil value is returned if the limit is unset.
+func parseLimit(t ResultType, s string) (*float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var v float64
+
+	// limits of arrays apply to each element
+	t, _ = arrayElementType(t)
+
+	switch t {
+	case Bool, String, Binary, JSON:
+		return nil, fmt.Errorf("not supported for non-numeric types")
+	case Uint8, Uint16, Uint32, Uint64:
+		u, err := strconv.ParseUint(s, 10, resultBits(t))
+		if err != nil {
+			return nil, err
+		}
+		v = float64(u)
+	case Int8, Int16, Int32, Int64:
+		i, err := strconv.ParseInt(s, 10, resultBits(t))
+		if err != nil {
+			return nil, err
+		}
+		v = float64(i)
+	case Float32, Float64:
+		f, err := strconv.ParseFloat(s, resultBits(t))
+		if err != nil {
+			return nil, err
+		}
+		v = f
+	}
+
+	return &v, nil
+}
+
+// resultBits returns the size in bits of a numeric ResultType.
+func resultBits(t ResultType) int {
+	switch t {
+	case Uint8, Int8:
+		return 8
+	case Uint16, Int16:
+		return 16
+	case Uint32, Int32, Float32:
+		return 32
+	}
+
+	return 64
+}
+
+// lineOf returns the 1-based line number of the first line in src after
+// the given section key which sets key to value, or 0 if not found. It's
+// used to provide line context for problems found in a profile after it's
+// been unmarshaled. If value is empty, the line of the key itself is
+// returned.
+func lineOf(src []byte, section string, key string, value string) int {
+	if src == nil {
+		return 0
+	}
+
+	// match both block and flow style mappings
+	pattern := `(^|[\s{,\-])"?` + regexp.QuoteMeta(key) + `"?\s*:`
+	if value != "" {
+		pattern += `\s*["']?` + regexp.QuoteMeta(value) + `["']?\s*([,}]|$)`
+	}
+	re := regexp.MustCompile(pattern)
+
+	var secRe *regexp.Regexp
+	if section != "" {
+		secRe = regexp.MustCompile(`^[\s\-]*"?` + regexp.QuoteMeta(section) + `"?\s*:`)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	inSection := secRe == nil
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+
+		if !inSection {
+			inSection = secRe.MatchString(line)
+			continue
+		}
+
+		if re.MatchString(line) {
+			return n
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,317 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+const validProfile = `name: "Test-Profile"
+manufacturer: "Test"
+model: "T1"
+deviceResources:
+  -
+    name: "Temperature"
+    description: "Temperature sensor reading"
+    properties:
+      value:
+        { type: "Int16", readWrite: "R", minimum: "-40", maximum: "125" }
+      units:
+        { type: "String", readWrite: "R", defaultValue: "C" }
+  -
+    name: "Switch"
+    description: "On/off switch"
+    properties:
+      value:
+        { type: "Bool", readWrite: "RW" }
+resources:
+  -
+    name: "State"
+    get:
+      - { operation: "get", object: "Temperature", parameter: "Temperature" }
+      - { operation: "get", object: "Switch", parameter: "Switch" }
+commands:
+  -
+    name: "State"
+  -
+    name: "Temperature"
+`
+
+const invalidProfile = `name: "Bad-Profile"
+deviceResources:
+  -
+    name: "Temperature"
+    properties:
+      value:
+        { type: "Int8", readWrite: "R", minimum: "-40", maximum: "300" }
+  -
+    name: "Humidity"
+    properties:
+      value:
+        { type: "Decimal", readWrite: "X" }
+  -
+    name: "Label"
+    properties:
+      value:
+        { type: "String", readWrite: "R", minimum: "0" }
+resources:
+  -
+    name: "State"
+    get:
+      - { operation: "get", object: "Pressure", parameter: "Pressure" }
+commands:
+  -
+    name: "Missing"
+`
+
+func writeProfile(t *testing.T, dir string, name string, contents string) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestValidateProfileFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeProfile(t, dir, "valid.yaml", validProfile)
+	profiles, err := ValidateProfileFile(path)
+	if err != nil {
+		t.Fatalf("ValidateProfileFile: unexpected error for valid profile: %v", err)
+	}
+
+	if len(profiles) != 1 || profiles[0].Name != "Test-Profile" {
+		t.Errorf("ValidateProfileFile: wrong profiles: %v", profiles)
+	}
+
+	path = writeProfile(t, dir, "invalid.yaml", invalidProfile)
+	_, err = ValidateProfileFile(path)
+	errs, ok := err.(ProfileErrors)
+	if !ok {
+		t.Fatalf("ValidateProfileFile: expected ProfileErrors, got: %v", err)
+	}
+
+	var expected = []struct {
+		line int
+		msg  string
+	}{
+		{4, "invalid maximum"},
+		{9, "invalid readWrite"},
+		{9, "invalid type"},
+		{14, "invalid minimum"},
+		{22, "unknown device resource: Pressure"},
+		{25, "command Missing"},
+	}
+
+	if len(errs) != len(expected) {
+		t.Fatalf("ValidateProfileFile: expected %d problems, got %d:\n%v", len(expected), len(errs), errs)
+	}
+
+	for i, e := range expected {
+		if errs[i].File != path || errs[i].Line != e.line || !strings.Contains(errs[i].Msg, e.msg) {
+			t.Errorf("ValidateProfileFile: problem #%d: expected %s:%d: ...%s..., got: %v",
+				i, path, e.line, e.msg, errs[i])
+		}
+	}
+}
+
+func TestValidateProfileFileUnmarshalError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeProfile(t, dir, "broken.yaml", "name: [\n")
+	_, err = ValidateProfileFile(path)
+	if err == nil || !strings.HasPrefix(err.Error(), path+":1: yaml: ") {
+		t.Errorf("ValidateProfileFile: expected unmarshal error prefixed by path and line, got: %v", err)
+	}
+}
+
+func TestValidatePropertyValue(t *testing.T) {
+	var tests = []struct {
+		name     string
+		typ      string
+		rw       string
+		min      string
+		max      string
+		problems int
+	}{
+		{"Valid uint8", "Uint8", "RW", "0", "255", 0},
+		{"Uint8 overflow", "Uint8", "R", "0", "256", 1},
+		{"Negative uint", "Uint16", "R", "-1", "", 1},
+		{"Valid float", "Float32", "W", "-1.5", "1e3", 0},
+		{"Legacy integer", "Integer", "R", "", "", 0},
+		{"Min greater than max", "Int32", "R", "10", "1", 1},
+		{"Bool with limit", "Bool", "R", "", "1", 1},
+		{"Empty readWrite", "String", "", "", "", 1},
+		{"Lowercase readWrite", "String", "rw", "", "", 0},
+		{"Repeated readWrite", "String", "RRW", "", "", 1},
+		{"Reversed readWrite", "String", "WR", "", "", 1},
+		{"Unknown type", "Complex", "R", "", "", 1},
+	}
+
+	transforms := []struct {
+		name     string
+		pv       models.PropertyValue
+		problems int
+	}{
+		{"Valid transforms", models.PropertyValue{Type: "Uint16", ReadWrite: "R", Mask: "0xFF", Shift: "-2", Scale: "0.5"}, 0},
+		{"Invalid scale", models.PropertyValue{Type: "Int32", ReadWrite: "R", Scale: "x"}, 1},
+		{"Float mask", models.PropertyValue{Type: "Float32", ReadWrite: "R", Mask: "1"}, 1},
+	}
+
+	for _, tt := range transforms {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := validatePropertyValue(tt.pv)
+			if len(msgs) != tt.problems {
+				t.Errorf("validatePropertyValue: expected %d problems, got: %v", tt.problems, msgs)
+			}
+		})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pv := models.PropertyValue{Type: tt.typ, ReadWrite: tt.rw, Minimum: tt.min, Maximum: tt.max}
+			msgs := validatePropertyValue(pv)
+			if len(msgs) != tt.problems {
+				t.Errorf("validatePropertyValue: expected %d problems, got: %v", tt.problems, msgs)
+			}
+		})
+	}
+}
+
+func TestValidateProfileMappings(t *testing.T) {
+	do := models.DeviceObject{Name: "Switch"}
+	do.Properties.Value = models.PropertyValue{Type: "Uint8", ReadWrite: "RW"}
+
+	profile := models.DeviceProfile{
+		Name:            "Test-Profile",
+		DeviceResources: []models.DeviceObject{do},
+		Resources: []models.ProfileResource{
+			{Name: "Power", Set: []models.ResourceOperation{
+				{Operation: "set", Object: "Switch", Parameter: "Switch",
+					Mappings: map[string]string{"0": "OFF", "1": "ON", "2": "ON", "3": "OFF", "4": "DIM"}},
+			}},
+		},
+	}
+
+	err := ValidateProfile(profile)
+	errs, ok := err.(ProfileErrors)
+	if !ok {
+		t.Fatalf("ValidateProfile: expected ProfileErrors, got: %v", err)
+	}
+
+	if len(errs) != 1 || !strings.Contains(errs[0].Msg, "operation set maps several values to: OFF, ON") {
+		t.Errorf("ValidateProfile: expected duplicate mappings error, got: %v", err)
+	}
+
+	profile.Resources[0].Set[0].Mappings = map[string]string{"0": "OFF", "1": "ON"}
+	if err := ValidateProfile(profile); err != nil {
+		t.Errorf("ValidateProfile: unexpected error: %v", err)
+	}
+}
+
+func TestValidateProfileFileMultiDocument(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the second document starts on line 31, and its first problem
+	// is on line 4 of the document
+	contents := validProfile + "---\n" + invalidProfile + "...\n---\n" +
+		strings.Replace(validProfile, "Test-Profile", "Other-Profile", 1)
+	path := writeProfile(t, dir, "multi.yml", contents)
+
+	profiles, err := ValidateProfileFile(path)
+	if len(profiles) != 2 || profiles[0].Name != "Test-Profile" || profiles[1].Name != "Other-Profile" {
+		t.Errorf("ValidateProfileFile: wrong valid profiles: %v", profileNames(profiles))
+	}
+
+	errs, ok := err.(ProfileErrors)
+	if !ok || len(errs) != 6 {
+		t.Fatalf("ValidateProfileFile: expected 6 problems, got: %v", err)
+	}
+
+	if errs[0].Profile != "Bad-Profile" || errs[0].Line != 34 {
+		t.Errorf("ValidateProfileFile: wrong context for first problem: %v", errs[0])
+	}
+}
+
+func TestValidateProfileFileJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const profile = `{
+  "name": "%s",
+  "deviceResources": [
+    {
+      "name": "Switch",
+      "properties": { "value": { "type": "Bool", "readWrite": "%s" } }
+    }
+  ]
+}`
+
+	path := writeProfile(t, dir, "single.json", fmt.Sprintf(profile, "Json-Profile", "RW"))
+	profiles, err := ValidateProfileFile(path)
+	if err != nil || len(profiles) != 1 || profiles[0].Name != "Json-Profile" {
+		t.Errorf("ValidateProfileFile: single JSON profile; profiles: %v err: %v", profileNames(profiles), err)
+	}
+
+	array := "[\n" + fmt.Sprintf(profile, "First", "R") + ",\n" + fmt.Sprintf(profile, "Second", "Z") + "\n]\n"
+	path = writeProfile(t, dir, "array.JSON", array)
+	profiles, err = ValidateProfileFile(path)
+	if len(profiles) != 1 || profiles[0].Name != "First" {
+		t.Errorf("ValidateProfileFile: JSON array; wrong valid profiles: %v", profileNames(profiles))
+	}
+
+	errs, ok := err.(ProfileErrors)
+	if !ok || len(errs) != 1 || errs[0].Profile != "Second" || errs[0].Line != 15 {
+		t.Errorf("ValidateProfileFile: JSON array; expected invalid readWrite on line 15, got: %v", err)
+	}
+
+	path = writeProfile(t, dir, "broken.json", "{\n  \"name\": \"Broken\",\n  \"deviceResources\": [,]\n}\n")
+	_, err = ValidateProfileFile(path)
+	errs, ok = err.(ProfileErrors)
+	if !ok || len(errs) != 1 || errs[0].Line != 3 {
+		t.Errorf("ValidateProfileFile: expected JSON syntax error on line 3, got: %v", err)
+	}
+}
+
+func TestIsProfileFile(t *testing.T) {
+	for _, name := range []string{"a.yaml", "a.YAML", "a.yml", "a.json", "a.Json"} {
+		if !isProfileFile(name) {
+			t.Errorf("isProfileFile: %s not accepted", name)
+		}
+	}
+
+	for _, name := range []string{"a.toml", "yaml", "a.yaml.bak"} {
+		if isProfileFile(name) {
+			t.Errorf("isProfileFile: %s accepted", name)
+		}
+	}
+}
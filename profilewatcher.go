@@ -0,0 +1,224 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+// profileFile records the state of a deviceprofile file
+// in the ProfilesDir when it was last loaded.
+type profileFile struct {
+	modTime time.Time
+	size    int64
+	// profiles are the names of the valid profiles the file contained.
+	profiles []string
+}
+
+// profileWatcher polls the ProfilesDir for new, changed and deleted
+// deviceprofile files, so that profiles can be updated without having
+// to restart the device service.
+type profileWatcher struct {
+	dir      string
+	interval time.Duration
+	files    map[string]profileFile
+}
+
+// newProfileWatcher creates a profileWatcher for the given directory. The
+// current contents of the directory are recorded, as these have already
+// been handled by loadProfiles.
+func newProfileWatcher(dir string, interval time.Duration) *profileWatcher {
+	w := &profileWatcher{dir: dir, interval: interval, files: make(map[string]profileFile)}
+
+	fileInfo, err := ioutil.ReadDir(dir)
+	if err != nil {
+		svc.lc.Error(fmt.Sprintf("profiles: couldn't read directory: %s; %v", dir, err))
+		return w
+	}
+
+	for _, file := range fileInfo {
+		if isProfileFile(file.Name()) {
+			path := dir + "/" + file.Name()
+
+			valid, _ := ValidateProfileFile(path)
+			w.files[path] = profileFile{modTime: file.ModTime(), size: file.Size(), profiles: profileNames(valid)}
+		}
+	}
+
+	return w
+}
+
+// watchProfiles polls the given ProfilesDir at the specified interval
+// until the service is stopped.
+func watchProfiles(path string, interval time.Duration) {
+	dir, err := profilesDir(path)
+	if err != nil {
+		svc.lc.Error(fmt.Sprintf("profiles: couldn't create absolute path for: %s; %v", path, err))
+		return
+	}
+
+	svc.lc.Info(fmt.Sprintf("profiles: watching %s for changes every %v", dir, interval))
+
+	w := newProfileWatcher(dir, interval)
+	for !svc.stopped {
+		time.Sleep(w.interval)
+		w.poll()
+	}
+}
+
+// poll checks the ProfilesDir once, and handles any files
+// which have been added, changed or deleted since the last poll.
+func (w *profileWatcher) poll() {
+	fileInfo, err := ioutil.ReadDir(w.dir)
+	if err != nil {
+		svc.lc.Error(fmt.Sprintf("profiles: couldn't read directory: %s; %v", w.dir, err))
+		return
+	}
+
+	seen := make(map[string]bool)
+
+	for _, file := range fileInfo {
+		if !isProfileFile(file.Name()) {
+			continue
+		}
+
+		path := w.dir + "/" + file.Name()
+		seen[path] = true
+
+		f, ok := w.files[path]
+		if ok && f.modTime.Equal(file.ModTime()) && f.size == file.Size() {
+			continue
+		}
+
+		f.modTime = file.ModTime()
+		f.size = file.Size()
+		f.profiles = w.reload(path)
+		w.files[path] = f
+	}
+
+	for path, f := range w.files {
+		if !seen[path] {
+			delete(w.files, path)
+			w.remove(path, f.profiles)
+		}
+	}
+}
+
+// reload validates the deviceprofile file at path, and pushes each valid
+// profile it contains to Core Metadata. Any devices in the cache using the
+// profiles are then re-provisioned. The names of the valid profiles are
+// returned.
+func (w *profileWatcher) reload(path string) []string {
+	valid, err := ValidateProfileFile(path)
+	if err != nil {
+		svc.lc.Error(fmt.Sprintf("profiles: invalid deviceprofiles in: %s skipped\n%v", path, err))
+	}
+
+	for _, profile := range valid {
+		w.push(path, profile)
+	}
+
+	return profileNames(valid)
+}
+
+// push adds the given profile to Core Metadata, or updates it if
+// it already exists.
+func (w *profileWatcher) push(path string, profile models.DeviceProfile) {
+	// TODO: this is the best test for not-found for now...
+	existing, err := svc.dpc.DeviceProfileForName(profile.Name)
+	if err != nil || existing.Name != profile.Name {
+		svc.lc.Info(fmt.Sprintf("profiles: adding new deviceprofile: %s from: %s", profile.Name, path))
+
+		err = addProfile(&profile)
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("profiles: Add device profile: %s to Core Metadata failed: %v", profile.Name, err))
+		}
+
+		return
+	}
+
+	svc.lc.Info(fmt.Sprintf("profiles: updating deviceprofile: %s from: %s", profile.Name, path))
+
+	profile.Id = existing.Id
+	err = svc.dpc.Update(profile)
+	if err != nil {
+		svc.lc.Error(fmt.Sprintf("profiles: Update device profile: %s in Core Metadata failed: %v", profile.Name, err))
+		return
+	}
+
+	pc.mutex.Lock()
+	pc.profiles[profile.Name] = profile
+	pc.mutex.Unlock()
+
+	reprovisionDevices(profile)
+}
+
+// reprovisionDevices replaces each cached device using the given profile
+// with a copy which has the new profile, and re-creates the device's
+// resources and commands in the profileCache. Commands in progress keep
+// using the device as it was.
+func reprovisionDevices(profile models.DeviceProfile) {
+	for _, d := range dc.Devices() {
+		if d.Profile.Name != profile.Name {
+			continue
+		}
+
+		svc.lc.Debug(fmt.Sprintf("profiles: re-provisioning dev: %s with profile: %s", d.Name, profile.Name))
+
+		dev := *d
+		dev.Profile = profile
+		err := pc.updateDevice(&dev)
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("profiles: couldn't re-provision dev: %s; %v", d.Name, err))
+			continue
+		}
+
+		dc.Replace(&dev)
+	}
+}
+
+// remove handles deletion of a deviceprofile file. The profiles it
+// contained are only removed from Core Metadata if ProfilesRemoveDeleted
+// is set.
+func (w *profileWatcher) remove(path string, names []string) {
+	if len(names) == 0 {
+		svc.lc.Info(fmt.Sprintf("profiles: file: %s with no valid deviceprofiles deleted", path))
+		return
+	}
+
+	if !svc.c.Device.ProfilesRemoveDeleted {
+		svc.lc.Warn(fmt.Sprintf("profiles: file: %s for deviceprofiles: %v deleted; profiles not removed from Core Metadata", path, names))
+		return
+	}
+
+	for _, name := range names {
+		svc.lc.Info(fmt.Sprintf("profiles: file: %s deleted; removing deviceprofile: %s", path, name))
+
+		err := svc.dpc.DeleteByName(name)
+		if err != nil {
+			svc.lc.Error(fmt.Sprintf("profiles: Delete device profile: %s from Core Metadata failed: %v", name, err))
+			continue
+		}
+
+		pc.mutex.Lock()
+		delete(pc.profiles, name)
+		pc.mutex.Unlock()
+	}
+}
+
+func profileNames(profiles []models.DeviceProfile) []string {
+	names := make([]string, len(profiles))
+	for i, profile := range profiles {
+		names[i] = profile.Name
+	}
+
+	return names
+}
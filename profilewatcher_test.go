@@ -0,0 +1,191 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/device-sdk-go/mock"
+	"github.com/edgexfoundry/edgex-go/pkg/clients/coredata"
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// valueDescriptorClient is a ValueDescriptorClient with no descriptors.
+type valueDescriptorClient struct {
+	coredata.ValueDescriptorClient
+}
+
+func (c valueDescriptorClient) ValueDescriptors() ([]models.ValueDescriptor, error) {
+	return nil, nil
+}
+
+func (c valueDescriptorClient) Add(vd *models.ValueDescriptor) (string, error) {
+	return bson.NewObjectId().Hex(), nil
+}
+
+func TestProfileWatcherPoll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dpc := &mock.DeviceProfileClientMock{}
+	svc = &Service{Name: "profilewatcher-test", c: &Config{}, dpc: dpc}
+	svc.lc = logger.NewClient("profilewatcher_test", false, "")
+	pc = &profileCache{profiles: make(map[string]models.DeviceProfile)}
+	dc = &deviceCache{devices: make(map[string]*models.Device), names: make(map[string]string)}
+
+	writeProfile(t, dir, "existing.yaml", validProfile)
+	w := newProfileWatcher(dir, time.Second)
+
+	if len(w.files) != 1 {
+		t.Fatalf("newProfileWatcher: expected 1 file, got: %v", w.files)
+	}
+
+	// unchanged files aren't reloaded
+	w.poll()
+	if len(dpc.Profiles) != 0 {
+		t.Errorf("poll: unchanged profile pushed to metadata: %v", dpc.Profiles)
+	}
+
+	newProfile := strings.Replace(validProfile, "Test-Profile", "New-Profile", 1)
+	path := writeProfile(t, dir, "new.yaml", newProfile)
+	w.poll()
+
+	if _, ok := dpc.Profiles["New-Profile"]; !ok {
+		t.Fatalf("poll: new profile not added to metadata")
+	}
+
+	if _, ok := pc.profiles["New-Profile"]; !ok {
+		t.Errorf("poll: new profile not added to cache")
+	}
+
+	changed := strings.Replace(newProfile, `model: "T1"`, `model: "T2"`, 1)
+	writeProfile(t, dir, "new.yaml", changed)
+	w.poll()
+
+	if model := dpc.Profiles["New-Profile"].Model; model != "T2" {
+		t.Errorf("poll: changed profile not updated in metadata; model: %s", model)
+	}
+
+	invalid := strings.Replace(changed, `readWrite: "RW"`, `readWrite: "X"`, 1)
+	writeProfile(t, dir, "new.yaml", invalid)
+	w.poll()
+
+	if model := dpc.Profiles["New-Profile"].Model; model != "T2" {
+		t.Errorf("poll: invalid profile pushed to metadata")
+	}
+
+	os.Remove(path)
+	w.poll()
+
+	if _, ok := w.files[path]; ok {
+		t.Errorf("poll: deleted file still being watched")
+	}
+
+	if _, ok := dpc.Profiles["New-Profile"]; !ok {
+		t.Errorf("poll: profile of invalid deleted file removed from metadata")
+	}
+}
+
+func TestProfileWatcherRemoveDeleted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dpc := &mock.DeviceProfileClientMock{}
+	svc = &Service{Name: "profilewatcher-test", c: &Config{}, dpc: dpc}
+	svc.lc = logger.NewClient("profilewatcher_test", false, "")
+	pc = &profileCache{profiles: make(map[string]models.DeviceProfile)}
+	dc = &deviceCache{devices: make(map[string]*models.Device), names: make(map[string]string)}
+
+	w := newProfileWatcher(dir, time.Second)
+	path := writeProfile(t, dir, "test.yaml", validProfile)
+	w.poll()
+
+	os.Remove(path)
+	w.poll()
+
+	if _, ok := dpc.Profiles["Test-Profile"]; !ok {
+		t.Fatalf("poll: profile removed from metadata by default")
+	}
+
+	svc.c.Device.ProfilesRemoveDeleted = true
+	writeProfile(t, filepath.Dir(path), filepath.Base(path), validProfile)
+	w.poll()
+
+	os.Remove(path)
+	w.poll()
+
+	if _, ok := dpc.Profiles["Test-Profile"]; ok {
+		t.Errorf("poll: profile not removed from metadata with ProfilesRemoveDeleted set")
+	}
+
+	if _, ok := pc.profiles["Test-Profile"]; ok {
+		t.Errorf("poll: profile not removed from cache with ProfilesRemoveDeleted set")
+	}
+}
+
+func TestReprovisionDevices(t *testing.T) {
+	svc = &Service{Name: "profilewatcher-test", c: &Config{}, vdc: valueDescriptorClient{}}
+	svc.lc = logger.NewClient("profilewatcher_test", false, "")
+	pc = &profileCache{
+		objects:  make(map[string]map[string]models.DeviceObject),
+		commands: make(map[string]map[string]map[string][]models.ResourceOperation),
+		profiles: make(map[string]models.DeviceProfile),
+	}
+
+	resource := func(name string) models.DeviceObject {
+		do := models.DeviceObject{Name: name}
+		do.Properties.Value = models.PropertyValue{Type: "Float64", ReadWrite: "R"}
+		return do
+	}
+
+	profile := models.DeviceProfile{Name: "Meter-Profile", DeviceResources: []models.DeviceObject{resource("temperature")}}
+	old := &models.Device{Name: "meter", Id: bson.NewObjectId(), Profile: profile}
+	dc = &deviceCache{devices: map[string]*models.Device{old.Name: old}, names: map[string]string{old.Id.Hex(): old.Name}}
+
+	// commands in progress read the cached device concurrently
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if d := dc.DeviceById(old.Id.Hex()); d == nil || len(d.Profile.DeviceResources) == 0 {
+				t.Errorf("DeviceById: device missing during re-provisioning")
+				return
+			}
+		}
+	}()
+
+	updated := profile
+	updated.DeviceResources = append(updated.DeviceResources, resource("humidity"))
+	reprovisionDevices(updated)
+	<-done
+
+	dev := dc.Device("meter")
+	if dev == old || len(dev.Profile.DeviceResources) != 2 {
+		t.Errorf("reprovisionDevices: device not replaced with updated profile: %v", dev.Profile.DeviceResources)
+	}
+
+	if len(old.Profile.DeviceResources) != 1 {
+		t.Errorf("reprovisionDevices: device in use was modified")
+	}
+
+	if exists, err := pc.CommandExists("meter", "humidity"); !exists || err != nil {
+		t.Errorf("reprovisionDevices: new command not added; err: %v", err)
+	}
+}
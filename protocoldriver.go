@@ -7,6 +7,8 @@
 package device
 
 import (
+	"context"
+
 	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
 	"github.com/edgexfoundry/edgex-go/pkg/models"
 )
@@ -39,8 +41,13 @@ type ProtocolDriver interface {
 	// an optional JSON encoded string specifying paramters for the individual
 	// commands.
 	//
+	// The given context is cancelled if the client disconnects, or when the
+	// command deadline (Service.Timeout, or the device's CommandTimeouts
+	// entry) expires. Drivers should abandon device I/O once ctx is done;
+	// any results returned after that are discarded.
+	//
 	// TODO: add param to CommandRequest and have command endpoint parse the params.
-	HandleCommands(d models.Device, reqs []CommandRequest, params string) ([]CommandResult, error)
+	HandleCommands(ctx context.Context, d models.Device, reqs []CommandRequest, params string) ([]CommandResult, error)
 
 	// Stop instructs the protocol-specific DS code to shutdown gracefully, or
 	// if the force parameter is 'true', immediately. The driver is responsible
@@ -48,3 +55,23 @@ type ProtocolDriver interface {
 	// readings (if supported).
 	Stop(force bool) error
 }
+
+// DeviceLifecycle is an optional interface implemented by ProtocolDrivers
+// which need to know when devices are added to, updated in, or removed
+// from the device service, e.g. to open and close connections. The
+// ConnectionSpec of the device's Addressable is passed if its protocol has
+// a parser (see ParseAddressable), and is nil otherwise, or if the
+// Addressable of a device already in Core Metadata is invalid. Errors
+// returned by the hooks are logged.
+type DeviceLifecycle interface {
+	// DeviceAdded is called when a device is added to the device service,
+	// including the devices of the service in Core Metadata at start-up.
+	DeviceAdded(dev *models.Device, spec ConnectionSpec) error
+
+	// DeviceUpdated is called when a device is updated.
+	DeviceUpdated(dev *models.Device, spec ConnectionSpec) error
+
+	// DeviceRemoved is called when a device is removed, once any commands
+	// in progress have completed.
+	DeviceRemoved(dev *models.Device) error
+}
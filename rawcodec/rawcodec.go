@@ -0,0 +1,543 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rawcodec decodes the raw bytes read from a device into
+// CommandResults, and encodes PUT values into raw bytes, as described by
+// the attributes of the DeviceObject being read or set:
+//
+//	rawType:   the type of each value on the device (Uint8 ... Float64);
+//	           the DeviceObject's PropertyValue type by default
+//	signed:    "true" or "false"; overrides the signedness of an integer
+//	           rawType
+//	byteOrder: "big" (the default) or "little"
+//	wordSwap:  "true" if the 16-bit words of a 32 or 64-bit value are in
+//	           reverse order
+//
+// Raw values are converted to the DeviceObject's PropertyValue type, which
+// may be a numeric array type if the raw bytes hold several values. An
+// error is returned if a value doesn't fit in the type it's converted to.
+package rawcodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	device "github.com/edgexfoundry/device-sdk-go"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+const (
+	// RawTypeAttribute is the DeviceObject attribute which specifies the
+	// type of values on the device.
+	RawTypeAttribute = "rawType"
+	// SignedAttribute is the DeviceObject attribute which specifies
+	// whether integer values on the device are signed.
+	SignedAttribute = "signed"
+	// ByteOrderAttribute is the DeviceObject attribute which specifies
+	// the byte order of values on the device; BigEndian or LittleEndian.
+	ByteOrderAttribute = "byteOrder"
+	// WordSwapAttribute is the DeviceObject attribute which specifies
+	// whether the 16-bit words of values on the device are swapped.
+	WordSwapAttribute = "wordSwap"
+
+	// BigEndian is the byte order with the most significant byte first.
+	BigEndian = "big"
+	// LittleEndian is the byte order with the least significant byte first.
+	LittleEndian = "little"
+)
+
+type kind int
+
+const (
+	unsignedKind kind = iota
+	signedKind
+	floatKind
+	boolKind
+)
+
+// numType is the type of a single value.
+type numType struct {
+	kind kind
+	bits uint
+}
+
+var types = map[string]numType{
+	"bool":    {boolKind, 8},
+	"boolean": {boolKind, 8},
+	"uint8":   {unsignedKind, 8},
+	"uint16":  {unsignedKind, 16},
+	"uint32":  {unsignedKind, 32},
+	"uint64":  {unsignedKind, 64},
+	"int8":    {signedKind, 8},
+	"int16":   {signedKind, 16},
+	"int32":   {signedKind, 32},
+	"int64":   {signedKind, 64},
+	"integer": {signedKind, 64},
+	"float32": {floatKind, 32},
+	"float64": {floatKind, 64},
+	"float":   {floatKind, 64},
+}
+
+var resultTypes = map[numType]device.ResultType{
+	{boolKind, 8}:      device.Bool,
+	{unsignedKind, 8}:  device.Uint8,
+	{unsignedKind, 16}: device.Uint16,
+	{unsignedKind, 32}: device.Uint32,
+	{unsignedKind, 64}: device.Uint64,
+	{signedKind, 8}:    device.Int8,
+	{signedKind, 16}:   device.Int16,
+	{signedKind, 32}:   device.Int32,
+	{signedKind, 64}:   device.Int64,
+	{floatKind, 32}:    device.Float32,
+	{floatKind, 64}:    device.Float64,
+}
+
+var arrayResultTypes = map[numType]device.ResultType{
+	{unsignedKind, 8}:  device.Uint8Array,
+	{unsignedKind, 16}: device.Uint16Array,
+	{unsignedKind, 32}: device.Uint32Array,
+	{unsignedKind, 64}: device.Uint64Array,
+	{signedKind, 8}:    device.Int8Array,
+	{signedKind, 16}:   device.Int16Array,
+	{signedKind, 32}:   device.Int32Array,
+	{signedKind, 64}:   device.Int64Array,
+	{floatKind, 32}:    device.Float32Array,
+	{floatKind, 64}:    device.Float64Array,
+}
+
+// Codec decodes and encodes the raw values of a DeviceObject.
+type Codec struct {
+	name     string
+	raw      numType
+	result   numType
+	array    bool
+	order    binary.ByteOrder
+	wordSwap bool
+}
+
+// New returns the Codec described by the attributes and PropertyValue
+// type of the given DeviceObject. A Bool DeviceObject has a Uint8 rawType
+// by default.
+func New(devObj *models.DeviceObject) (*Codec, error) {
+	c := &Codec{name: devObj.Name, order: binary.BigEndian}
+
+	pvType := strings.ToLower(devObj.Properties.Value.Type)
+	if strings.HasSuffix(pvType, "array") {
+		pvType = strings.TrimSuffix(pvType, "array")
+		c.array = true
+	}
+
+	var ok bool
+	c.result, ok = types[pvType]
+	if !ok || (c.array && c.result.kind == boolKind) {
+		return nil, fmt.Errorf("devobject: %s has unsupported type: %s", c.name, devObj.Properties.Value.Type)
+	}
+
+	c.raw = c.result
+	if c.raw.kind == boolKind {
+		c.raw = numType{unsignedKind, 8}
+	}
+
+	attrs := devObj.Attributes
+	if rawType, ok := device.AttributeString(attrs, RawTypeAttribute); ok {
+		c.raw, ok = types[strings.ToLower(rawType)]
+		if !ok || c.raw.kind == boolKind {
+			return nil, fmt.Errorf("devobject: %s has invalid %s: %s", c.name, RawTypeAttribute, rawType)
+		}
+	}
+
+	if signed, ok := device.AttributeString(attrs, SignedAttribute); ok {
+		s, err := strconv.ParseBool(signed)
+		if err != nil || c.raw.kind == floatKind {
+			return nil, fmt.Errorf("devobject: %s has invalid %s: %s", c.name, SignedAttribute, signed)
+		}
+
+		c.raw.kind = unsignedKind
+		if s {
+			c.raw.kind = signedKind
+		}
+	}
+
+	if order, ok := device.AttributeString(attrs, ByteOrderAttribute); ok {
+		switch strings.ToLower(order) {
+		case BigEndian:
+		case LittleEndian:
+			c.order = binary.LittleEndian
+		default:
+			return nil, fmt.Errorf("devobject: %s has invalid %s: %s", c.name, ByteOrderAttribute, order)
+		}
+	}
+
+	if swap, ok := device.AttributeString(attrs, WordSwapAttribute); ok {
+		s, err := strconv.ParseBool(swap)
+		if err != nil {
+			return nil, fmt.Errorf("devobject: %s has invalid %s: %s", c.name, WordSwapAttribute, swap)
+		}
+
+		if s && c.raw.bits < 32 {
+			return nil, fmt.Errorf("devobject: %s %s requires a 32 or 64-bit %s", c.name, WordSwapAttribute, RawTypeAttribute)
+		}
+		c.wordSwap = s
+	}
+
+	return c, nil
+}
+
+// Size returns the number of raw bytes of a single value.
+func (c *Codec) Size() int {
+	return int(c.raw.bits / 8)
+}
+
+// Decode returns the CommandResult of the given raw bytes, which must
+// hold a single value, or any number of values if the DeviceObject's type
+// is an array type.
+func (c *Codec) Decode(ro *models.ResourceOperation, origin int64, raw []byte) (*device.CommandResult, error) {
+	size := c.Size()
+	if len(raw) == 0 || len(raw)%size != 0 || (!c.array && len(raw) != size) {
+		return nil, fmt.Errorf("devobject: %s expected %d raw bytes per value, got: %d", c.name, size, len(raw))
+	}
+
+	cr := &device.CommandResult{RO: ro, Origin: origin}
+	if c.array {
+		cr.Type = arrayResultTypes[c.result]
+	} else {
+		cr.Type = resultTypes[c.result]
+	}
+
+	for b := raw; len(b) > 0; b = b[size:] {
+		n, err := c.fromRaw(b[:size]).convert(c.result)
+		if err != nil {
+			return nil, fmt.Errorf("devobject: %s %v", c.name, err)
+		}
+
+		if c.result.kind == boolKind {
+			cr.BoolResult = n.u != 0
+			continue
+		}
+
+		buf := make([]byte, c.result.bits/8)
+		n.put(buf, binary.BigEndian)
+		cr.NumericResult = append(cr.NumericResult, buf...)
+	}
+
+	return cr, nil
+}
+
+// Encode returns the raw bytes of the given value, which is a bool, a
+// string, a json.Number or a Go numeric type, or a []interface{} of these
+// (or a string holding a JSON array) if the DeviceObject's type is an
+// array type.
+func (c *Codec) Encode(value interface{}) ([]byte, error) {
+	values := []interface{}{value}
+	if c.array {
+		if s, ok := value.(string); ok {
+			dec := json.NewDecoder(bytes.NewBufferString(s))
+			dec.UseNumber()
+			if err := dec.Decode(&value); err != nil {
+				return nil, fmt.Errorf("devobject: %s value: %s isn't a JSON array", c.name, s)
+			}
+		}
+
+		var ok bool
+		values, ok = value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("devobject: %s value: %v isn't an array", c.name, value)
+		}
+	}
+
+	var raw []byte
+	for _, v := range values {
+		n, err := parse(v, c.result)
+		if err == nil {
+			n, err = n.convert(c.result)
+		}
+		if err == nil {
+			n, err = n.convert(c.raw)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("devobject: %s %v", c.name, err)
+		}
+
+		buf := make([]byte, c.Size())
+		n.put(buf, c.order)
+		if c.wordSwap {
+			swapWords(buf)
+		}
+		raw = append(raw, buf...)
+	}
+
+	return raw, nil
+}
+
+// Decode returns the CommandResult of the raw bytes read for the given
+// CommandRequest.
+func Decode(req *device.CommandRequest, origin int64, raw []byte) (*device.CommandResult, error) {
+	c, err := New(&req.DeviceObject)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Decode(&req.RO, origin, raw)
+}
+
+// EncodeParam returns the raw bytes of the value of the given
+// CommandRequest's parameter, taken from the JSON encoded parameters of a
+// PUT command.
+func EncodeParam(req *device.CommandRequest, params string) ([]byte, error) {
+	c, err := New(&req.DeviceObject)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	dec := json.NewDecoder(bytes.NewBufferString(params))
+	dec.UseNumber()
+	if err := dec.Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid parameters; expected JSON object: %v", err)
+	}
+
+	v, ok := values[req.RO.Parameter]
+	if !ok {
+		return nil, fmt.Errorf("no value for parameter: %s", req.RO.Parameter)
+	}
+
+	return c.Encode(v)
+}
+
+// fromRaw returns the number held by the raw bytes of a single value.
+func (c *Codec) fromRaw(b []byte) number {
+	if c.wordSwap {
+		b = append([]byte(nil), b...)
+		swapWords(b)
+	}
+
+	n := number{t: c.raw}
+	switch c.raw.bits {
+	case 8:
+		n.u = uint64(b[0])
+	case 16:
+		n.u = uint64(c.order.Uint16(b))
+	case 32:
+		n.u = uint64(c.order.Uint32(b))
+	default:
+		n.u = c.order.Uint64(b)
+	}
+
+	return n
+}
+
+// swapWords reverses the order of the 16-bit words of b.
+func swapWords(b []byte) {
+	for i, j := 0, len(b)-2; i < j; i, j = i+2, j-2 {
+		b[i], b[i+1], b[j], b[j+1] = b[j], b[j+1], b[i], b[i+1]
+	}
+}
+
+// number is a value of a numType. u holds the value of an unsigned or bool
+// number, the two's complement value of a signed number, or the IEEE-754
+// bits of a float.
+type number struct {
+	t numType
+	u uint64
+}
+
+func (n number) signed() int64 {
+	shift := 64 - n.t.bits
+	return int64(n.u<<shift) >> shift
+}
+
+func (n number) float() float64 {
+	if n.t.bits == 32 {
+		return float64(math.Float32frombits(uint32(n.u)))
+	}
+
+	return math.Float64frombits(n.u)
+}
+
+func (n number) String() string {
+	switch n.t.kind {
+	case signedKind:
+		return strconv.FormatInt(n.signed(), 10)
+	case floatKind:
+		return strconv.FormatFloat(n.float(), 'g', -1, int(n.t.bits))
+	case boolKind:
+		return strconv.FormatBool(n.u != 0)
+	}
+
+	return strconv.FormatUint(n.u, 10)
+}
+
+// put stores the number in b, which is its size, in the given byte order.
+func (n number) put(b []byte, order binary.ByteOrder) {
+	switch len(b) {
+	case 1:
+		b[0] = uint8(n.u)
+	case 2:
+		order.PutUint16(b, uint16(n.u))
+	case 4:
+		order.PutUint32(b, uint32(n.u))
+	default:
+		order.PutUint64(b, n.u)
+	}
+}
+
+// convert returns the number converted to the given type. Floats are only
+// converted to integers if they're whole numbers.
+func (n number) convert(t numType) (number, error) {
+	out := number{t: t}
+	overflow := fmt.Errorf("value: %v overflows %v", n, resultTypes[t])
+
+	switch t.kind {
+	case boolKind:
+		if n.t.kind == floatKind {
+			out.u = boolBits(n.float() != 0)
+		} else {
+			out.u = boolBits(n.u != 0)
+		}
+		return out, nil
+
+	case floatKind:
+		var f float64
+		switch n.t.kind {
+		case signedKind:
+			f = float64(n.signed())
+		case floatKind:
+			f = n.float()
+		default:
+			f = float64(n.u)
+		}
+
+		if t.bits == 64 {
+			out.u = math.Float64bits(f)
+			return out, nil
+		}
+
+		if math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+			return out, overflow
+		}
+		out.u = uint64(math.Float32bits(float32(f)))
+		return out, nil
+	}
+
+	limit := math.Ldexp(1, int(t.bits))
+	if t.kind == signedKind {
+		limit /= 2
+	}
+
+	switch n.t.kind {
+	case floatKind:
+		f := n.float()
+		if f != math.Trunc(f) || math.IsInf(f, 0) {
+			return out, fmt.Errorf("value: %v isn't an integer", n)
+		}
+
+		if f >= limit || (t.kind == unsignedKind && f < 0) || (t.kind == signedKind && f < -limit) {
+			return out, overflow
+		}
+
+		if t.kind == signedKind {
+			out.u = uint64(int64(f))
+		} else {
+			out.u = uint64(f)
+		}
+
+	case signedKind:
+		i := n.signed()
+		if (t.kind == unsignedKind && i < 0) || (t.bits < 64 && (float64(i) >= limit || float64(i) < -limit)) {
+			return out, overflow
+		}
+		out.u = uint64(i)
+
+	default:
+		if (t.bits < 64 && float64(n.u) >= limit) || (t.kind == signedKind && n.u > math.MaxInt64) {
+			return out, overflow
+		}
+		out.u = n.u
+	}
+
+	if t.bits < 64 {
+		out.u &= 1<<t.bits - 1
+	}
+
+	return out, nil
+}
+
+func boolBits(b bool) uint64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// parse returns the number of a value being encoded, which is of the
+// given result type if it's a string.
+func parse(v interface{}, t numType) (number, error) {
+	switch v := v.(type) {
+	case bool:
+		return number{numType{boolKind, 8}, boolBits(v)}, nil
+	case json.Number:
+		return parseString(string(v), t)
+	case string:
+		return parseString(v, t)
+	case float64:
+		return number{numType{floatKind, 64}, math.Float64bits(v)}, nil
+	case float32:
+		return number{numType{floatKind, 64}, math.Float64bits(float64(v))}, nil
+	case int:
+		return number{numType{signedKind, 64}, uint64(v)}, nil
+	case int8:
+		return number{numType{signedKind, 64}, uint64(v)}, nil
+	case int16:
+		return number{numType{signedKind, 64}, uint64(v)}, nil
+	case int32:
+		return number{numType{signedKind, 64}, uint64(v)}, nil
+	case int64:
+		return number{numType{signedKind, 64}, uint64(v)}, nil
+	case uint:
+		return number{numType{unsignedKind, 64}, uint64(v)}, nil
+	case uint8:
+		return number{numType{unsignedKind, 64}, uint64(v)}, nil
+	case uint16:
+		return number{numType{unsignedKind, 64}, uint64(v)}, nil
+	case uint32:
+		return number{numType{unsignedKind, 64}, uint64(v)}, nil
+	case uint64:
+		return number{numType{unsignedKind, 64}, v}, nil
+	}
+
+	return number{}, fmt.Errorf("value: %v has unsupported type: %T", v, v)
+}
+
+func parseString(s string, t numType) (number, error) {
+	if t.kind == boolKind {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return number{}, fmt.Errorf("value: %s isn't a bool", s)
+		}
+		return number{t, boolBits(b)}, nil
+	}
+
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return number{numType{unsignedKind, 64}, u}, nil
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return number{numType{signedKind, 64}, uint64(i)}, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return number{}, fmt.Errorf("value: %s isn't a number", s)
+	}
+
+	return number{numType{floatKind, 64}, math.Float64bits(f)}, nil
+}
@@ -0,0 +1,225 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rawcodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	device "github.com/edgexfoundry/device-sdk-go"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+func deviceObject(pvType string, attrs map[string]interface{}) *models.DeviceObject {
+	do := &models.DeviceObject{Name: "register", Attributes: attrs}
+	do.Properties.Value.Type = pvType
+	return do
+}
+
+func TestNew(t *testing.T) {
+	var tests = []struct {
+		name   string
+		pvType string
+		attrs  map[string]interface{}
+		valid  bool
+	}{
+		{"Default", "Int32", nil, true},
+		{"Raw type", "Float64", map[string]interface{}{RawTypeAttribute: "Int16"}, true},
+		{"Little endian", "Uint32", map[string]interface{}{ByteOrderAttribute: "little", WordSwapAttribute: true}, true},
+		{"Array", "Uint16Array", nil, true},
+		{"Bool array", "BoolArray", nil, false},
+		{"String", "String", nil, false},
+		{"Bad raw type", "Int32", map[string]interface{}{RawTypeAttribute: "Bool"}, false},
+		{"Signed float", "Float32", map[string]interface{}{SignedAttribute: "true"}, false},
+		{"Bad byte order", "Int32", map[string]interface{}{ByteOrderAttribute: "middle"}, false},
+		{"Short word swap", "Int16", map[string]interface{}{WordSwapAttribute: "true"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(deviceObject(tt.pvType, tt.attrs))
+			if tt.valid && err != nil {
+				t.Errorf("New: unexpected error: %v", err)
+			} else if !tt.valid && err == nil {
+				t.Errorf("New: expected error")
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var tests = []struct {
+		name     string
+		pvType   string
+		attrs    map[string]interface{}
+		raw      []byte
+		expected string
+	}{
+		{"Big endian", "Uint32", nil, []byte{0x12, 0x34, 0x56, 0x78}, "305419896"},
+		{"Little endian", "Uint32", map[string]interface{}{ByteOrderAttribute: LittleEndian}, []byte{0x78, 0x56, 0x34, 0x12}, "305419896"},
+		{"Word swap", "Uint32", map[string]interface{}{WordSwapAttribute: "true"}, []byte{0x56, 0x78, 0x12, 0x34}, "305419896"},
+		{"Little endian word swap", "Uint32", map[string]interface{}{ByteOrderAttribute: LittleEndian, WordSwapAttribute: "true"}, []byte{0x34, 0x12, 0x78, 0x56}, "305419896"},
+		{"Signed raw", "Int32", map[string]interface{}{RawTypeAttribute: "Int16"}, []byte{0xff, 0xfe}, "-2"},
+		{"Signed override", "Float64", map[string]interface{}{RawTypeAttribute: "Uint16", SignedAttribute: "true"}, []byte{0xff, 0xfe}, "-2"},
+		{"Float raw", "Float32", map[string]interface{}{WordSwapAttribute: "true"}, []byte{0x00, 0x00, 0x40, 0x49}, "3.140625"},
+		{"Integral float raw", "Int8", map[string]interface{}{RawTypeAttribute: "Float32"}, []byte{0xc2, 0xc8, 0x00, 0x00}, "-100"},
+		{"Bool", "Bool", nil, []byte{0x01}, "true"},
+		{"Bool raw", "Bool", map[string]interface{}{RawTypeAttribute: "Uint16"}, []byte{0x00, 0x00}, "false"},
+		{"Array", "Int16Array", map[string]interface{}{ByteOrderAttribute: LittleEndian}, []byte{0x01, 0x00, 0xff, 0xff}, "[1,-1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(deviceObject(tt.pvType, tt.attrs))
+			if err != nil {
+				t.Fatalf("New: unexpected error: %v", err)
+			}
+
+			cr, err := c.Decode(&models.ResourceOperation{Object: "register"}, 42, tt.raw)
+			if err != nil {
+				t.Fatalf("Decode: unexpected error: %v", err)
+			}
+
+			if cr.Type.String() != tt.pvType || cr.Origin != 42 {
+				t.Errorf("Decode: expected type: %s origin: 42, got: %v %d", tt.pvType, cr.Type, cr.Origin)
+			}
+
+			if s := resultString(t, cr); s != tt.expected {
+				t.Errorf("Decode: expected: %s, got: %s", tt.expected, s)
+			}
+		})
+	}
+}
+
+func resultString(t *testing.T, cr *device.CommandResult) string {
+	var v interface{}
+	var err error
+
+	switch cr.Type {
+	case device.Bool:
+		v, err = cr.BoolValue()
+	case device.Uint32:
+		v, err = cr.Uint32Value()
+	case device.Int8:
+		v, err = cr.Int8Value()
+	case device.Int32:
+		v, err = cr.Int32Value()
+	case device.Float32:
+		v, err = cr.Float32Value()
+	case device.Float64:
+		v, err = cr.Float64Value()
+	default:
+		v, err = cr.ArrayValue()
+	}
+
+	if err != nil {
+		t.Fatalf("resultString: unexpected error: %v", err)
+	}
+
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var tests = []struct {
+		name   string
+		pvType string
+		attrs  map[string]interface{}
+		raw    []byte
+	}{
+		{"Short", "Uint32", nil, []byte{0x12, 0x34}},
+		{"Too many values", "Uint16", nil, []byte{0x12, 0x34, 0x56, 0x78}},
+		{"Ragged array", "Uint16Array", nil, []byte{0x12, 0x34, 0x56}},
+		{"Overflow", "Uint8", map[string]interface{}{RawTypeAttribute: "Uint16"}, []byte{0x01, 0x00}},
+		{"Negative unsigned", "Uint16", map[string]interface{}{RawTypeAttribute: "Int16"}, []byte{0xff, 0xff}},
+		{"Fractional", "Int32", map[string]interface{}{RawTypeAttribute: "Float32"}, []byte{0x40, 0x49, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(deviceObject(tt.pvType, tt.attrs))
+			if err != nil {
+				t.Fatalf("New: unexpected error: %v", err)
+			}
+
+			if _, err := c.Decode(&models.ResourceOperation{}, 0, tt.raw); err == nil {
+				t.Errorf("Decode: expected error")
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	var tests = []struct {
+		name     string
+		pvType   string
+		attrs    map[string]interface{}
+		value    interface{}
+		expected []byte
+		valid    bool
+	}{
+		{"Number", "Uint32", map[string]interface{}{ByteOrderAttribute: LittleEndian, WordSwapAttribute: "true"}, json.Number("305419896"), []byte{0x34, 0x12, 0x78, 0x56}, true},
+		{"String", "Int32", map[string]interface{}{RawTypeAttribute: "Int16"}, "-2", []byte{0xff, 0xfe}, true},
+		{"Float to integer raw", "Float64", map[string]interface{}{RawTypeAttribute: "Uint16"}, 258.0, []byte{0x01, 0x02}, true},
+		{"Float", "Float32", nil, json.Number("3.140625"), []byte{0x40, 0x49, 0x00, 0x00}, true},
+		{"Bool", "Bool", nil, "true", []byte{0x01}, true},
+		{"Array", "Int16Array", nil, []interface{}{json.Number("1"), -1}, []byte{0x00, 0x01, 0xff, 0xff}, true},
+		{"Array string", "Uint8Array", nil, "[1, 2]", []byte{0x01, 0x02}, true},
+		{"Out of type range", "Uint8", map[string]interface{}{RawTypeAttribute: "Uint16"}, 256, nil, false},
+		{"Out of raw range", "Int32", map[string]interface{}{RawTypeAttribute: "Int8"}, "128", nil, false},
+		{"Fractional", "Float64", map[string]interface{}{RawTypeAttribute: "Int16"}, 1.5, nil, false},
+		{"Not a number", "Int32", nil, "ten", nil, false},
+		{"Not an array", "Int16Array", nil, 1, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(deviceObject(tt.pvType, tt.attrs))
+			if err != nil {
+				t.Fatalf("New: unexpected error: %v", err)
+			}
+
+			raw, err := c.Encode(tt.value)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("Encode: expected error, got: %x", raw)
+				}
+				return
+			}
+
+			if err != nil || !bytes.Equal(raw, tt.expected) {
+				t.Errorf("Encode: expected: %x, got: %x, %v", tt.expected, raw, err)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	req := &device.CommandRequest{
+		RO:           models.ResourceOperation{Object: "register", Parameter: "setpoint"},
+		DeviceObject: *deviceObject("Float64", map[string]interface{}{RawTypeAttribute: "Float32", ByteOrderAttribute: LittleEndian, WordSwapAttribute: "true"}),
+	}
+
+	raw, err := EncodeParam(req, `{"setpoint": 21.5}`)
+	if err != nil {
+		t.Fatalf("EncodeParam: unexpected error: %v", err)
+	}
+
+	cr, err := Decode(req, 0, raw)
+	if err != nil {
+		t.Fatalf("Decode: unexpected error: %v", err)
+	}
+
+	if f, err := cr.Float64Value(); err != nil || f != 21.5 {
+		t.Errorf("Decode: expected: 21.5, got: %v, %v", f, err)
+	}
+
+	if _, err := EncodeParam(req, `{"other": 1}`); err == nil {
+		t.Errorf("EncodeParam: expected error for missing parameter")
+	}
+}
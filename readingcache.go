@@ -0,0 +1,137 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+// LastReading is returned by the last reading endpoint.
+type LastReading struct {
+	Device string `json:"device"`
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	// Origin is the origin of the reading, as set by the ProtocolDriver.
+	Origin int64 `json:"origin"`
+	// Age is the time (in milliseconds) since the reading was received
+	// from the ProtocolDriver.
+	Age int64 `json:"age"`
+	// ClockDrift is set if the device's timestamps currently drift from
+	// the service clock by more than MaxClockDrift.
+	ClockDrift bool `json:"clockDrift,omitempty"`
+}
+
+type cachedReading struct {
+	reading  models.Reading
+	received time.Time
+}
+
+// readingCache holds the last reading of each device resource, fed by
+// the events produced by the DS, so that the last known values can be
+// queried without a device read.
+type readingCache struct {
+	mutex    sync.RWMutex
+	readings map[string]map[string]cachedReading
+}
+
+var rc = newReadingCache()
+
+func newReadingCache() *readingCache {
+	return &readingCache{readings: make(map[string]map[string]cachedReading)}
+}
+
+// update records the readings of the given event.
+func (c *readingCache) update(event *models.Event) {
+	now := clock.Now()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	devReadings, ok := c.readings[event.Device]
+	if !ok {
+		devReadings = make(map[string]cachedReading)
+		c.readings[event.Device] = devReadings
+	}
+
+	for _, r := range event.Readings {
+		devReadings[r.Name] = cachedReading{reading: r, received: now}
+	}
+}
+
+// lastReading returns the last reading of the named device resource.
+func (c *readingCache) lastReading(devName string, name string) (LastReading, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	cr, ok := c.readings[devName][name]
+	if !ok {
+		return LastReading{}, false
+	}
+
+	return LastReading{
+		Device:     devName,
+		Name:       name,
+		Value:      cr.reading.Value,
+		Origin:     cr.reading.Origin,
+		Age:        int64(clock.Now().Sub(cr.received) / time.Millisecond),
+		ClockDrift: dt.isDrifting(devName),
+	}, true
+}
+
+// removeDevice discards the readings of the named device.
+func (c *readingCache) removeDevice(devName string) {
+	c.mutex.Lock()
+	delete(c.readings, devName)
+	c.mutex.Unlock()
+}
+
+// recordEvent records an event produced by the DS, from either a command
+// or an async reading, in the local stores. Events without readings, e.g.
+// from PUT commands, aren't recorded.
+func recordEvent(event *models.Event) {
+	if len(event.Readings) == 0 {
+		return
+	}
+
+	rc.update(event)
+
+	if eb != nil {
+		eb.add(event)
+	}
+}
+
+func lastReadingFunc(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	resource := vars["resource"]
+
+	d := dc.DeviceById(id)
+	if d == nil {
+		msg := fmt.Sprintf("dev: %s not found; %s %s", id, r.Method, r.URL)
+		svc.lc.Error(msg)
+		writeError(w, http.StatusNotFound, msg, id, resource) // status=404
+		return
+	}
+
+	reading, ok := rc.lastReading(d.Name, resource)
+	if !ok {
+		msg := fmt.Sprintf("no reading: %s for dev: %s; %s %s", resource, d.Name, r.Method, r.URL)
+		svc.lc.Debug(msg)
+		writeError(w, http.StatusNotFound, msg, d.Name, resource) // status=404
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reading)
+}
@@ -0,0 +1,78 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+	"github.com/gorilla/mux"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestReadingCache(t *testing.T) {
+	cache := newReadingCache()
+	cache.update(&models.Event{Device: "dev", Readings: []models.Reading{
+		{Name: "Temperature", Value: "21.5", Origin: 1000},
+		{Name: "Humidity", Value: "40", Origin: 1000},
+	}})
+	cache.update(&models.Event{Device: "dev", Readings: []models.Reading{{Name: "Temperature", Value: "22", Origin: 2000}}})
+
+	r, ok := cache.lastReading("dev", "Temperature")
+	if !ok || r.Value != "22" || r.Origin != 2000 || r.Age < 0 {
+		t.Errorf("lastReading: wrong reading: %v", r)
+	}
+
+	r, ok = cache.lastReading("dev", "Humidity")
+	if !ok || r.Value != "40" {
+		t.Errorf("lastReading: wrong reading: %v", r)
+	}
+
+	cache.removeDevice("dev")
+	if _, ok = cache.lastReading("dev", "Temperature"); ok {
+		t.Errorf("lastReading: reading of removed device returned")
+	}
+}
+
+func TestLastReadingFunc(t *testing.T) {
+	lc := logger.NewClient("readingcache_test", false, "")
+	r := mux.NewRouter().PathPrefix(apiV1).Subrouter()
+	svc = &Service{Name: "readingcache-test", c: &Config{}, lc: lc, r: r}
+	initCommand()
+
+	dev := &models.Device{Name: "sensor", Id: bson.NewObjectId()}
+	cache := &deviceCache{}
+	cache.InitDeviceCache()
+	cache.devices[dev.Name] = dev
+	cache.names[dev.Id.Hex()] = dev.Name
+	dc = cache
+
+	rc = newReadingCache()
+	recordEvent(&models.Event{Device: dev.Name, Readings: []models.Reading{{Name: "Temperature", Value: "21.5", Origin: 1000}}})
+
+	rr := httptest.NewRecorder()
+	svc.r.ServeHTTP(rr, httptest.NewRequest("GET", v1Device+"/"+dev.Id.Hex()+"/reading/Temperature", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("lastReadingFunc: wrong status code: %d", rr.Code)
+	}
+
+	var reading LastReading
+	err := json.NewDecoder(rr.Body).Decode(&reading)
+	if err != nil || reading.Device != dev.Name || reading.Value != "21.5" || reading.Origin != 1000 {
+		t.Errorf("lastReadingFunc: wrong reading: %v; err: %v", reading, err)
+	}
+
+	rr = httptest.NewRecorder()
+	svc.r.ServeHTTP(rr, httptest.NewRequest("GET", v1Device+"/"+dev.Id.Hex()+"/reading/Humidity", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("lastReadingFunc: missing reading returned: %d", rr.Code)
+	}
+}
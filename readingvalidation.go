@@ -0,0 +1,256 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+const (
+	// ValidationAttribute is the DeviceObject attribute which specifies
+	// how results which are out of the range of the object's
+	// PropertyValue, or of the wrong type, are handled. It overrides
+	// Device.ResultValidation; one of the Validation policies below.
+	ValidationAttribute = "validation"
+
+	// ValidationNone disables validation.
+	ValidationNone = "none"
+	// ValidationReject fails the command, or drops the async reading.
+	ValidationReject = "reject"
+	// ValidationClamp replaces out of range values with the nearest limit.
+	// Results of the wrong type are rejected.
+	ValidationClamp = "clamp"
+	// ValidationMark passes the reading through, followed by a quality
+	// reading named <resource>_quality whose value is the violation.
+	ValidationMark = "mark"
+
+	// QualityOutOfRange is the quality of a value outside the minimum
+	// and maximum of its PropertyValue.
+	QualityOutOfRange = "outOfRange"
+	// QualityWrongType is the quality of a result whose type doesn't
+	// match the type of its PropertyValue.
+	QualityWrongType = "wrongType"
+)
+
+// ValidationStats counts the results of a device resource which failed
+// validation.
+type ValidationStats struct {
+	OutOfRange int64 `json:"outOfRange"`
+	WrongType  int64 `json:"wrongType"`
+	Rejected   int64 `json:"rejected"`
+	Clamped    int64 `json:"clamped"`
+	Marked     int64 `json:"marked"`
+}
+
+// validationStats holds the ValidationStats of each device resource.
+type validationStats struct {
+	mutex sync.Mutex
+	// stats is keyed by device name, then resource name
+	stats map[string]map[string]*ValidationStats
+}
+
+var vs = newValidationStats()
+
+func newValidationStats() *validationStats {
+	return &validationStats{stats: make(map[string]map[string]*ValidationStats)}
+}
+
+// count records a violation by a result of the given device resource,
+// and the action taken.
+func (s *validationStats) count(devName string, name string, quality string, policy string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	devStats, ok := s.stats[devName]
+	if !ok {
+		devStats = make(map[string]*ValidationStats)
+		s.stats[devName] = devStats
+	}
+
+	st, ok := devStats[name]
+	if !ok {
+		st = &ValidationStats{}
+		devStats[name] = st
+	}
+
+	if quality == QualityWrongType {
+		st.WrongType++
+	} else {
+		st.OutOfRange++
+	}
+
+	switch policy {
+	case ValidationReject:
+		st.Rejected++
+	case ValidationClamp:
+		st.Clamped++
+	case ValidationMark:
+		st.Marked++
+	}
+}
+
+// snapshot returns a copy of the statistics.
+func (s *validationStats) snapshot() map[string]map[string]ValidationStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := make(map[string]map[string]ValidationStats, len(s.stats))
+	for devName, devStats := range s.stats {
+		stats[devName] = make(map[string]ValidationStats, len(devStats))
+		for name, st := range devStats {
+			stats[devName][name] = *st
+		}
+	}
+
+	return stats
+}
+
+// removeDevice discards the statistics of the named device.
+func (s *validationStats) removeDevice(devName string) {
+	s.mutex.Lock()
+	delete(s.stats, devName)
+	s.mutex.Unlock()
+}
+
+// validationOf returns the validation policy of the given DeviceObject,
+// or the given default policy if the object doesn't specify one.
+func validationOf(devObj *models.DeviceObject, def string) (string, error) {
+	policy, ok := AttributeString(devObj.Attributes, ValidationAttribute)
+	if !ok {
+		policy = def
+	}
+
+	switch policy {
+	case "":
+		return ValidationNone, nil
+	case ValidationNone, ValidationReject, ValidationClamp, ValidationMark:
+		return policy, nil
+	}
+
+	return ValidationNone, fmt.Errorf("devobject: %s has invalid %s: %s", devObj.Name, ValidationAttribute, policy)
+}
+
+// qualityName returns the name of the quality reading of a resource.
+func qualityName(name string) string {
+	return name + "_quality"
+}
+
+// qualityReading returns the quality reading of the given reading.
+func qualityReading(reading *models.Reading, quality string) models.Reading {
+	return models.Reading{
+		Device: reading.Device,
+		Name:   qualityName(reading.Name),
+		Value:  quality,
+		Origin: reading.Origin,
+	}
+}
+
+// validateResult checks a (transformed) result against the type, minimum
+// and maximum of its DeviceObject's PropertyValue, and applies the
+// object's validation policy to a violation. An error is returned if the
+// result is rejected, and the quality to be marked otherwise, which is
+// empty if the result is valid or was clamped.
+func validateResult(cr *CommandResult, devName string, devObj *models.DeviceObject) (string, error) {
+	policy, err := validationOf(devObj, svc.c.Device.ResultValidation)
+	if err != nil {
+		svc.lc.Error(err.Error())
+	}
+
+	if policy == ValidationNone {
+		return "", nil
+	}
+
+	pv := devObj.Properties.Value
+	quality := ""
+	if !resultTypeMatches(cr.Type, pv.Type) {
+		quality = QualityWrongType
+	} else if !cr.inRange(pv, false) {
+		quality = QualityOutOfRange
+	}
+
+	if quality == "" {
+		return "", nil
+	}
+
+	if policy == ValidationClamp && quality == QualityWrongType {
+		policy = ValidationReject
+	}
+
+	vs.count(devName, devObj.Name, quality, policy)
+
+	switch policy {
+	case ValidationReject:
+		return "", fmt.Errorf("dev: %s resource: %s result: %s rejected; %s", devName, devObj.Name, cr.toString(), quality)
+	case ValidationClamp:
+		cr.inRange(pv, true)
+		return "", nil
+	}
+
+	return quality, nil
+}
+
+// resultTypeMatches returns whether a ResultType matches the type of a
+// PropertyValue. The legacy "integer" and "float" types match any integer
+// or float type, respectively, and unknown types match any result.
+func resultTypeMatches(t ResultType, pvType string) bool {
+	switch strings.ToLower(pvType) {
+	case "integer":
+		return t >= Uint8 && t <= Int64
+	case "float":
+		return t == Float32 || t == Float64
+	}
+
+	pt, ok := propertyTypes[strings.ToLower(pvType)]
+	return !ok || pt == t
+}
+
+// inRange returns whether a numeric result, or every element of a numeric
+// array result, is within the minimum and maximum of the PropertyValue.
+// NaN floats are out of range if a limit is set. If clamp is set, values
+// out of range are replaced by the nearest limit (or the minimum, for NaN).
+func (cr *CommandResult) inRange(pv models.PropertyValue, clamp bool) bool {
+	elem, _ := arrayElementType(cr.Type)
+	min, errMin := parseLimit(elem, pv.Minimum)
+	max, errMax := parseLimit(elem, pv.Maximum)
+	if errMin != nil || errMax != nil || (min == nil && max == nil) {
+		return true
+	}
+
+	ok := true
+	bits := uint(resultBits(elem))
+	for b := cr.NumericResult; len(b) >= int(bits/8); b = b[bits/8:] {
+		f := elementFloat(elem, getUint(b[:bits/8]), bits)
+
+		var limit *float64
+		switch {
+		case math.IsNaN(f) && min != nil:
+			limit = min
+		case math.IsNaN(f):
+			limit = max
+		case min != nil && f < *min:
+			limit = min
+		case max != nil && f > *max:
+			limit = max
+		default:
+			continue
+		}
+
+		ok = false
+		if clamp {
+			// limits are validated against the type, so can't overflow
+			u, _ := floatElement(elem, *limit, bits)
+			putUint(b[:bits/8], u)
+		}
+	}
+
+	return ok
+}
@@ -0,0 +1,104 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+func TestValidateResult(t *testing.T) {
+	lc := logger.NewClient("readingvalidation_test", false, "")
+	svc = &Service{Name: "readingvalidation-test", c: &Config{}, lc: lc}
+	vs = newValidationStats()
+
+	var tests = []struct {
+		name     string
+		cr       *CommandResult
+		typ      string
+		policy   string
+		quality  string
+		rejected bool
+		expected string
+	}{
+		{"In range", NewInt16Result(nil, nil, 0, 50), "Int16", ValidationReject, "", false, "50"},
+		{"Reject", NewInt16Result(nil, nil, 0, 150), "Int16", ValidationReject, "", true, "150"},
+		{"Clamp max", NewInt16Result(nil, nil, 0, 150), "Int16", ValidationClamp, "", false, "100"},
+		{"Clamp min", NewFloat64Result(nil, nil, 0, -0.5), "Float64", ValidationClamp, "", false, "0"},
+		{"Clamp NaN", NewFloat32Result(nil, nil, 0, float32(math.NaN())), "Float32", ValidationClamp, "", false, "0"},
+		{"Clamp array", NewUint8ArrayResult(nil, nil, 0, []uint8{5, 200}), "Uint8Array", ValidationClamp, "", false, "[5,100]"},
+		{"Mark", NewInt16Result(nil, nil, 0, 150), "Int16", ValidationMark, QualityOutOfRange, false, "150"},
+		{"Mark wrong type", NewStringResult(nil, nil, 0, "x"), "Int16", ValidationMark, QualityWrongType, false, "x"},
+		{"Clamp wrong type", NewInt32Result(nil, nil, 0, 50), "Int16", ValidationClamp, "", true, "50"},
+		{"Legacy integer", NewInt32Result(nil, nil, 0, 50), "Integer", ValidationReject, "", false, "50"},
+		{"None", NewInt16Result(nil, nil, 0, 150), "Int16", ValidationNone, "", false, "150"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			do := &models.DeviceObject{Name: "level", Attributes: map[string]interface{}{ValidationAttribute: tt.policy}}
+			do.Properties.Value = models.PropertyValue{Type: tt.typ, Minimum: "0", Maximum: "100"}
+
+			quality, err := validateResult(tt.cr, "dev", do)
+			if (err != nil) != tt.rejected {
+				t.Errorf("validateResult: expected rejected: %v, got: %v", tt.rejected, err)
+			}
+
+			if quality != tt.quality {
+				t.Errorf("validateResult: expected quality: %q, got: %q", tt.quality, quality)
+			}
+
+			if v := tt.cr.toString(); v != tt.expected {
+				t.Errorf("validateResult: expected value: %s, got: %s", tt.expected, v)
+			}
+		})
+	}
+
+	st := vs.snapshot()["dev"]["level"]
+	expected := ValidationStats{OutOfRange: 6, WrongType: 2, Rejected: 2, Clamped: 4, Marked: 2}
+	if st != expected {
+		t.Errorf("validationStats: expected: %+v, got: %+v", expected, st)
+	}
+}
+
+func TestValidationDefault(t *testing.T) {
+	do := &models.DeviceObject{Name: "level"}
+
+	if policy, err := validationOf(do, ValidationClamp); err != nil || policy != ValidationClamp {
+		t.Errorf("validationOf: expected default policy, got: %s, %v", policy, err)
+	}
+
+	do.Attributes = map[string]interface{}{ValidationAttribute: "ignore"}
+	if _, err := validationOf(do, ""); err == nil {
+		t.Errorf("validationOf: expected error for invalid policy")
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	lc := logger.NewClient("readingvalidation_test", false, "")
+	svc = &Service{Name: "readingvalidation-test", c: &Config{}, lc: lc, r: mux.NewRouter()}
+	vs = newValidationStats()
+	vs.count("dev", "level", QualityOutOfRange, ValidationMark)
+	initStatus()
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	rr := httptest.NewRecorder()
+	svc.r.ServeHTTP(rr, req)
+
+	var stats Statistics
+	err := json.NewDecoder(rr.Body).Decode(&stats)
+	if err != nil || stats.Validation["dev"]["level"].Marked != 1 {
+		t.Errorf("statsHandler: wrong statistics: %+v, %v", stats, err)
+	}
+}
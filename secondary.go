@@ -0,0 +1,66 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+// secondaryRequests returns a CommandRequest for each secondary device
+// object listed by the ResourceOperations of the given results, which the
+// ProtocolDriver hasn't already returned a result for. A driver can return
+// secondary results along with the primary result (e.g. a value and its
+// quality flag), by including a CommandResult whose RO.Object names the
+// secondary device object. Otherwise a follow-up read is required, using
+// the returned requests.
+func secondaryRequests(results []CommandResult, devObjs map[string]models.DeviceObject) ([]CommandRequest, error) {
+	var reqs []CommandRequest
+
+	have := make(map[string]bool)
+	for _, cr := range results {
+		if cr.RO != nil {
+			have[cr.RO.Object] = true
+		}
+	}
+
+	for _, cr := range results {
+		if cr.RO == nil {
+			continue
+		}
+
+		for _, name := range cr.RO.Secondary {
+			if have[name] {
+				continue
+			}
+
+			devObj, ok := devObjs[name]
+			if !ok {
+				return nil, fmt.Errorf("no devobject: %s for secondary of: %s", name, cr.RO.Object)
+			}
+
+			reqs = append(reqs, CommandRequest{RO: secondaryOperation(name), DeviceObject: devObj})
+			have[name] = true
+		}
+	}
+
+	return reqs, nil
+}
+
+// secondaryOperation returns the get ResourceOperation used to
+// read the secondary device object with the given name.
+func secondaryOperation(name string) models.ResourceOperation {
+	return models.ResourceOperation{
+		Index:     "1",
+		Object:    name,
+		Operation: "get",
+		Parameter: name,
+		Property:  "value",
+		Secondary: []string{},
+	}
+}
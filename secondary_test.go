@@ -0,0 +1,52 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+func TestSecondaryRequests(t *testing.T) {
+	devObjs := map[string]models.DeviceObject{
+		"Energy":        {Name: "Energy"},
+		"EnergyQuality": {Name: "EnergyQuality"},
+		"Power":         {Name: "Power"},
+		"PowerQuality":  {Name: "PowerQuality"},
+	}
+
+	energyOp := models.ResourceOperation{Operation: "get", Object: "Energy", Secondary: []string{"EnergyQuality"}}
+	powerOp := models.ResourceOperation{Operation: "get", Object: "Power", Secondary: []string{"PowerQuality", "EnergyQuality"}}
+	energyQualityOp := secondaryOperation("EnergyQuality")
+
+	// the driver returned the energy quality along with the energy value
+	results := []CommandResult{
+		*NewUint32Result(&energyOp, nil, 0, 1200),
+		*NewUint8Result(&energyQualityOp, nil, 0, 1),
+		*NewUint32Result(&powerOp, nil, 0, 50),
+	}
+
+	reqs, err := secondaryRequests(results, devObjs)
+	if err != nil {
+		t.Fatalf("secondaryRequests: unexpected error: %v", err)
+	}
+
+	if len(reqs) != 1 {
+		t.Fatalf("secondaryRequests: expected 1 request, got: %v", reqs)
+	}
+
+	if reqs[0].RO.Object != "PowerQuality" || reqs[0].RO.Operation != "get" || reqs[0].DeviceObject.Name != "PowerQuality" {
+		t.Errorf("secondaryRequests: wrong request: %v", reqs[0])
+	}
+
+	badOp := models.ResourceOperation{Operation: "get", Object: "Power", Secondary: []string{"Missing"}}
+	_, err = secondaryRequests([]CommandResult{*NewUint32Result(&badOp, nil, 0, 50)}, devObjs)
+	if err == nil {
+		t.Errorf("secondaryRequests: unknown secondary device object accepted")
+	}
+}
@@ -256,6 +256,13 @@ func (s *Service) Start(useRegistry bool, profile string, confDir string) (err e
 	newProfileCache()
 	newDeviceCache(s.ds.Service.Id.Hex())
 
+	if s.c.Device.ProfilesWatchInterval > 0 {
+		interval := time.Second * time.Duration(s.c.Device.ProfilesWatchInterval)
+		go watchProfiles(s.c.Device.ProfilesDir, interval)
+	}
+
+	go flushAggregates(aggregateFlushInterval)
+
 	// TODO: initialize scheduler
 
 	// initialize driver
@@ -274,17 +281,26 @@ func (s *Service) Start(useRegistry bool, profile string, confDir string) (err e
 
 	// Setup REST API
 	s.r = mux.NewRouter().PathPrefix(apiV1).Subrouter()
+	s.r.NotFoundHandler = http.HandlerFunc(notFoundHandler)
+	s.r.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowedHandler)
 	initStatus()
 	initCommand()
 	initControl()
 	initUpdate()
-
-	http.TimeoutHandler(nil, time.Millisecond*time.Duration(s.c.Service.Timeout), "Request timed out")
+	initEvents()
+
+	// Commands are bounded by their own deadlines (see commandContext),
+	// so the server timeout only limits reading the request.
+	server := &http.Server{
+		Addr:        colon + strconv.Itoa(s.c.Service.Port),
+		Handler:     correlationHandler(s.r),
+		ReadTimeout: time.Millisecond * time.Duration(s.c.Service.Timeout),
+	}
 
 	// TODO: call ListenAndServe in a goroutine
 
 	s.lc.Info("*Service Start() called")
-	s.lc.Error(http.ListenAndServe(colon+strconv.Itoa(s.c.Service.Port), s.r).Error())
+	s.lc.Error(server.ListenAndServe().Error())
 	s.lc.Debug("*Service Start() exit")
 
 	return err
@@ -7,14 +7,28 @@
 package device
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
 )
 
+// Statistics is returned by the statistics endpoint.
+type Statistics struct {
+	// Validation holds the ValidationStats of device resources whose
+	// results failed validation, keyed by device name, then resource name.
+	Validation map[string]map[string]ValidationStats `json:"validation"`
+}
+
 func statusHandler(w http.ResponseWriter, req *http.Request) {
 	io.WriteString(w, "pong")
 }
 
+func statsHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Statistics{Validation: vs.snapshot()})
+}
+
 func initStatus() {
 	svc.r.HandleFunc("/ping", statusHandler)
+	svc.r.HandleFunc("/stats", statsHandler).Methods(http.MethodGet)
 }
@@ -0,0 +1,133 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// TimestampMilliseconds specifies that reading and event origins are
+	// in milliseconds since the Unix epoch.
+	TimestampMilliseconds = "ms"
+	// TimestampNanoseconds specifies that reading and event origins are
+	// in nanoseconds since the Unix epoch.
+	TimestampNanoseconds = "ns"
+)
+
+// Clock is the source of the current time used to timestamp readings and
+// events. A ProtocolDriver may supply a Clock which returns device-side
+// time, using Service.SetClock.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+var clock Clock = systemClock{}
+
+// SetClock sets the Clock used to timestamp readings and events, or
+// restores the system clock if c is nil.
+func (s *Service) SetClock(c Clock) {
+	if c == nil {
+		c = systemClock{}
+	}
+	clock = c
+}
+
+// Timestamp returns the given time in the configured timestamp precision.
+// A ProtocolDriver which sets the Origin of CommandResults should use it
+// to convert device timestamps.
+func (s *Service) Timestamp(t time.Time) int64 {
+	return timestamp(t)
+}
+
+// timestampPrecision returns the unit of timestamps, as configured by
+// Device.TimestampPrecision. Milliseconds are used by default.
+func timestampPrecision() time.Duration {
+	if svc != nil && svc.c != nil && svc.c.Device.TimestampPrecision == TimestampNanoseconds {
+		return time.Nanosecond
+	}
+
+	return time.Millisecond
+}
+
+// timestamp returns the given time in the configured precision.
+func timestamp(t time.Time) int64 {
+	return t.UnixNano() / int64(timestampPrecision())
+}
+
+// now returns the current time of the clock in the configured precision.
+func now() int64 {
+	return timestamp(clock.Now())
+}
+
+// driftTracker flags devices whose timestamps drift from the service
+// clock by more than Device.MaxClockDrift. A warning is logged when a
+// device starts drifting, and again when it's back within tolerance.
+type driftTracker struct {
+	mutex    sync.Mutex
+	drifting map[string]bool
+}
+
+var dt = newDriftTracker()
+
+func newDriftTracker() *driftTracker {
+	return &driftTracker{drifting: make(map[string]bool)}
+}
+
+// check compares the origin of a reading set by the ProtocolDriver with
+// the service clock, and returns the drift, and whether it exceeds the
+// tolerance. Readings without an origin aren't checked.
+func (t *driftTracker) check(devName string, origin int64) (time.Duration, bool) {
+	if origin <= 0 || svc.c.Device.MaxClockDrift <= 0 {
+		return 0, false
+	}
+
+	drift := time.Duration(origin-now()) * timestampPrecision()
+	max := time.Duration(svc.c.Device.MaxClockDrift) * time.Millisecond
+	drifting := drift > max || drift < -max
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if drifting && !t.drifting[devName] {
+		svc.lc.Warn(fmt.Sprintf("dev: %s clock drift: %v exceeds MaxClockDrift: %v", devName, drift, max))
+	} else if !drifting && t.drifting[devName] {
+		svc.lc.Info(fmt.Sprintf("dev: %s clock drift: %v within MaxClockDrift: %v", devName, drift, max))
+	}
+
+	if drifting {
+		t.drifting[devName] = true
+	} else {
+		delete(t.drifting, devName)
+	}
+
+	return drift, drifting
+}
+
+// isDrifting returns whether the named device's timestamps are currently
+// drifting.
+func (t *driftTracker) isDrifting(devName string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.drifting[devName]
+}
+
+// removeDevice discards the drift state of the named device.
+func (t *driftTracker) removeDevice(devName string) {
+	t.mutex.Lock()
+	delete(t.drifting, devName)
+	t.mutex.Unlock()
+}
@@ -0,0 +1,95 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"testing"
+	"time"
+
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+)
+
+type fixedClock struct {
+	t time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.t
+}
+
+func TestTimestamp(t *testing.T) {
+	lc := logger.NewClient("timestamps_test", false, "")
+	svc = &Service{Name: "timestamps-test", c: &Config{}, lc: lc}
+
+	fixed := time.Unix(1500000000, 123456789)
+	svc.SetClock(fixedClock{fixed})
+	defer svc.SetClock(nil)
+
+	var tests = []struct {
+		name      string
+		precision string
+		expected  int64
+	}{
+		{"Default", "", 1500000000123},
+		{"Milliseconds", TimestampMilliseconds, 1500000000123},
+		{"Nanoseconds", TimestampNanoseconds, 1500000000123456789},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc.c.Device.TimestampPrecision = tt.precision
+
+			if ts := now(); ts != tt.expected {
+				t.Errorf("now: expected: %d, got: %d", tt.expected, ts)
+			}
+
+			reading := NewBoolResult(nil, nil, 0, true).Reading("FakeDevice", "FakeDeviceObject")
+			if reading.Origin != tt.expected {
+				t.Errorf("Reading: expected origin: %d, got: %d", tt.expected, reading.Origin)
+			}
+
+			if ts := svc.Timestamp(fixed); ts != tt.expected {
+				t.Errorf("Timestamp: expected: %d, got: %d", tt.expected, ts)
+			}
+		})
+	}
+}
+
+func TestDriftTracker(t *testing.T) {
+	lc := logger.NewClient("timestamps_test", false, "")
+	svc = &Service{Name: "timestamps-test", c: &Config{}, lc: lc}
+
+	svc.SetClock(fixedClock{time.Unix(1500000000, 0)})
+	defer svc.SetClock(nil)
+
+	tracker := newDriftTracker()
+	if _, drifting := tracker.check("dev", 1500000010000); drifting {
+		t.Errorf("check: drift flagged with MaxClockDrift unset")
+	}
+
+	svc.c.Device.MaxClockDrift = 5000
+
+	drift, drifting := tracker.check("dev", 1500000010000)
+	if !drifting || drift != 10*time.Second || !tracker.isDrifting("dev") {
+		t.Errorf("check: expected drift: 10s flagged, got: %v, %v", drift, drifting)
+	}
+
+	drift, drifting = tracker.check("dev", 1499999998000)
+	if drifting || drift != -2*time.Second || tracker.isDrifting("dev") {
+		t.Errorf("check: expected drift: -2s not flagged, got: %v, %v", drift, drifting)
+	}
+
+	if _, drifting = tracker.check("dev", 0); drifting {
+		t.Errorf("check: drift flagged for reading without origin")
+	}
+
+	tracker.check("dev", 1)
+	tracker.removeDevice("dev")
+	if tracker.isDrifting("dev") {
+		t.Errorf("removeDevice: drift state not removed")
+	}
+}
@@ -0,0 +1,229 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+// transforms holds the parsed transforms of a PropertyValue. Each is
+// applied only if set.
+type transforms struct {
+	mask                uint64
+	shift               int64
+	base, scale, offset float64
+	hasMask, hasShift   bool
+	hasBase, hasScale   bool
+	hasOffset           bool
+}
+
+// parseTransforms parses the transforms specified by a PropertyValue.
+// Masks may be given in decimal, hex (0x) or octal (0) notation.
+func parseTransforms(pv models.PropertyValue) (transforms, error) {
+	var tr transforms
+	var err error
+
+	if pv.Mask != "" {
+		tr.hasMask = true
+		tr.mask, err = strconv.ParseUint(pv.Mask, 0, 64)
+		if err != nil {
+			return tr, fmt.Errorf("invalid mask: %s", pv.Mask)
+		}
+	}
+
+	if pv.Shift != "" {
+		tr.hasShift = true
+		tr.shift, err = strconv.ParseInt(pv.Shift, 10, 8)
+		if err != nil {
+			return tr, fmt.Errorf("invalid shift: %s", pv.Shift)
+		}
+	}
+
+	for _, f := range []struct {
+		s   string
+		v   *float64
+		has *bool
+	}{
+		{pv.Base, &tr.base, &tr.hasBase},
+		{pv.Scale, &tr.scale, &tr.hasScale},
+		{pv.Offset, &tr.offset, &tr.hasOffset},
+	} {
+		if f.s == "" {
+			continue
+		}
+
+		*f.has = true
+		*f.v, err = strconv.ParseFloat(f.s, 64)
+		if err != nil {
+			return tr, fmt.Errorf("invalid transform: %s", f.s)
+		}
+	}
+
+	return tr, nil
+}
+
+// transform applies the mask, shift, base, scale and offset transforms
+// specified by the given PropertyValue, in that order, to a numeric
+// result, or to each element of a numeric array result. A positive shift
+// shifts left, and a negative shift right. Masks and shifts apply to the
+// bits of integer values, and aren't supported for floats. Base, scale and
+// offset are calculated in floating point; integer results are truncated
+// towards zero. An error is returned if a transformed value overflows its
+// type.
+func (cr *CommandResult) transform(pv models.PropertyValue) error {
+	tr, err := parseTransforms(pv)
+	if err != nil {
+		return err
+	}
+
+	elem, _ := arrayElementType(cr.Type)
+	switch elem {
+	case Bool, String:
+		if tr.hasMask || tr.hasShift || tr.hasBase || tr.hasScale || tr.hasOffset {
+			return fmt.Errorf("transforms not supported for %v result", cr.Type)
+		}
+		return nil
+	case Float32, Float64:
+		if tr.hasMask || tr.hasShift {
+			return fmt.Errorf("mask and shift not supported for %v result", cr.Type)
+		}
+	}
+
+	// transform a copy, so the result is unchanged on error
+	out := append([]byte(nil), cr.NumericResult...)
+	size := resultBits(elem) / 8
+	for b := out; len(b) >= size; b = b[size:] {
+		err = tr.apply(elem, b[:size])
+		if err != nil {
+			return err
+		}
+	}
+
+	cr.NumericResult = out
+	return nil
+}
+
+// apply applies the transforms to a single big-endian encoded value of
+// the given numeric type, in place.
+func (tr transforms) apply(t ResultType, b []byte) error {
+	bits := uint(len(b) * 8)
+	u := getUint(b)
+
+	if tr.hasMask {
+		u &= tr.mask
+	}
+
+	if tr.hasShift {
+		if tr.shift >= 0 {
+			if tr.shift >= int64(bits) || u>>(bits-uint(tr.shift)) != 0 {
+				return fmt.Errorf("shift: %d overflows %v", tr.shift, t)
+			}
+			u <<= uint(tr.shift)
+		} else if -tr.shift >= int64(bits) {
+			u = 0
+		} else {
+			u >>= uint(-tr.shift)
+		}
+	}
+
+	if !tr.hasBase && !tr.hasScale && !tr.hasOffset {
+		putUint(b, u)
+		return nil
+	}
+
+	f := elementFloat(t, u, bits)
+
+	if tr.hasBase {
+		f = math.Pow(tr.base, f)
+	}
+
+	if tr.hasScale {
+		f *= tr.scale
+	}
+
+	if tr.hasOffset {
+		f += tr.offset
+	}
+
+	u, err := floatElement(t, f, bits)
+	if err != nil {
+		return err
+	}
+
+	putUint(b, u)
+	return nil
+}
+
+// elementFloat returns the value of the given bits of a numeric value of
+// the given type and size, as a float64.
+func elementFloat(t ResultType, u uint64, bits uint) float64 {
+	switch t {
+	case Int8, Int16, Int32, Int64:
+		// sign-extend the value to 64 bits
+		return float64(int64(u<<(64-bits)) >> (64 - bits))
+	case Float32:
+		return float64(math.Float32frombits(uint32(u)))
+	case Float64:
+		return math.Float64frombits(u)
+	}
+
+	return float64(u)
+}
+
+// floatElement returns the bits of f converted to a numeric value of the
+// given type and size. Integers are truncated towards zero. An error is
+// returned if f overflows the type.
+func floatElement(t ResultType, f float64, bits uint) (uint64, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("value overflows %v", t)
+	}
+
+	switch t {
+	case Uint8, Uint16, Uint32, Uint64:
+		f = math.Trunc(f)
+		if f < 0 || f >= math.Ldexp(1, int(bits)) {
+			return 0, fmt.Errorf("value: %g overflows %v", f, t)
+		}
+		return uint64(f), nil
+	case Int8, Int16, Int32, Int64:
+		f = math.Trunc(f)
+		if f < -math.Ldexp(1, int(bits-1)) || f >= math.Ldexp(1, int(bits-1)) {
+			return 0, fmt.Errorf("value: %g overflows %v", f, t)
+		}
+		return uint64(int64(f)), nil
+	case Float32:
+		if math.Abs(f) > math.MaxFloat32 {
+			return 0, fmt.Errorf("value: %g overflows %v", f, t)
+		}
+		return uint64(math.Float32bits(float32(f))), nil
+	}
+
+	return math.Float64bits(f), nil
+}
+
+// getUint returns the big-endian encoded bits of a numeric value.
+func getUint(b []byte) uint64 {
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+
+	return u
+}
+
+// putUint big-endian encodes the low bits of u into b, truncating it to
+// the length of b.
+func putUint(b []byte, u uint64) {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = byte(u)
+		u >>= 8
+	}
+}
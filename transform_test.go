@@ -0,0 +1,54 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+func TestTransformResult(t *testing.T) {
+	var tests = []struct {
+		name     string
+		cr       *CommandResult
+		pv       models.PropertyValue
+		ok       bool
+		expected string
+	}{
+		{"Uint16 scale offset", NewUint16Result(nil, nil, 0, 100), models.PropertyValue{Scale: "2", Offset: "5"}, true, "205"},
+		{"Uint8 mask shift", NewUint8Result(nil, nil, 0, 0xF3), models.PropertyValue{Mask: "0xF0", Shift: "-4"}, true, "15"},
+		{"Uint8 shift overflow", NewUint8Result(nil, nil, 0, 0x81), models.PropertyValue{Shift: "1"}, false, "129"},
+		{"Int16 negative scale", NewInt16Result(nil, nil, 0, -300), models.PropertyValue{Scale: "0.1"}, true, "-30"},
+		{"Int8 overflow", NewInt8Result(nil, nil, 0, 100), models.PropertyValue{Scale: "2"}, false, "100"},
+		{"Uint32 negative", NewUint32Result(nil, nil, 0, 1), models.PropertyValue{Offset: "-2"}, false, "1"},
+		{"Int32 base", NewInt32Result(nil, nil, 0, 3), models.PropertyValue{Base: "10"}, true, "1000"},
+		{"Float32 scale", NewFloat32Result(nil, nil, 0, 1.5), models.PropertyValue{Scale: "2", Offset: "0.25"}, true, "3.25"},
+		{"Float64 offset", NewFloat64Result(nil, nil, 0, 20), models.PropertyValue{Offset: "-273.15"}, true, "-253.14999999999998"},
+		{"Float32 overflow", NewFloat32Result(nil, nil, 0, 3e38), models.PropertyValue{Scale: "10"}, false, "300000000000000000000000000000000000000"},
+		{"Float mask", NewFloat64Result(nil, nil, 0, 1), models.PropertyValue{Mask: "1"}, false, "1"},
+		{"Array scale", NewInt16ArrayResult(nil, nil, 0, []int16{1, -2}), models.PropertyValue{Scale: "3"}, true, "[3,-6]"},
+		{"Invalid scale", NewInt64Result(nil, nil, 0, 1), models.PropertyValue{Scale: "x"}, false, "1"},
+		{"String scale", NewStringResult(nil, nil, 0, "a"), models.PropertyValue{Scale: "2"}, false, "a"},
+		{"Assertion match", NewUint8Result(nil, nil, 0, 5), models.PropertyValue{Offset: "1", Assertion: "6"}, true, "6"},
+		{"Assertion mismatch", NewStringResult(nil, nil, 0, "fault"), models.PropertyValue{Assertion: "ok"}, false, "fault"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok := tt.cr.TransformResult(tt.pv)
+			if ok != tt.ok {
+				t.Errorf("TransformResult: expected: %v, got: %v", tt.ok, ok)
+			}
+
+			reading := tt.cr.Reading("FakeDevice", "FakeDeviceObject")
+			if reading.Value != tt.expected {
+				t.Errorf("TransformResult: expected reading value: %s, got: %s", tt.expected, reading.Value)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+// PublishUnitsAttribute is the DeviceObject attribute which specifies the
+// unit in which readings of the object are published, if it differs from
+// the device unit given by the object's Units. Results are converted to
+// the published unit, and PUT parameters from it.
+const PublishUnitsAttribute = "publishUnits"
+
+// unit defines a unit by its dimension, and its conversion to the base
+// unit of the dimension: base = value * scale + offset.
+type unit struct {
+	dimension     string
+	scale, offset float64
+}
+
+var unitsMutex sync.RWMutex
+
+// units is the unit table, keyed by unit symbol.
+var units = map[string]unit{
+	// temperature (K)
+	"K":  {"temperature", 1, 0},
+	"°C": {"temperature", 1, 273.15},
+	"C":  {"temperature", 1, 273.15},
+	"°F": {"temperature", 5.0 / 9, 273.15 - 32*5.0/9},
+	"F":  {"temperature", 5.0 / 9, 273.15 - 32*5.0/9},
+
+	// pressure (Pa)
+	"Pa":   {"pressure", 1, 0},
+	"hPa":  {"pressure", 100, 0},
+	"kPa":  {"pressure", 1000, 0},
+	"MPa":  {"pressure", 1e6, 0},
+	"mbar": {"pressure", 100, 0},
+	"bar":  {"pressure", 1e5, 0},
+	"psi":  {"pressure", 6894.757293168361, 0},
+	"atm":  {"pressure", 101325, 0},
+	"mmHg": {"pressure", 133.322387415, 0},
+	"inHg": {"pressure", 3386.389, 0},
+
+	// length (m)
+	"mm": {"length", 0.001, 0},
+	"cm": {"length", 0.01, 0},
+	"m":  {"length", 1, 0},
+	"km": {"length", 1000, 0},
+	"in": {"length", 0.0254, 0},
+	"ft": {"length", 0.3048, 0},
+	"yd": {"length", 0.9144, 0},
+	"mi": {"length", 1609.344, 0},
+
+	// mass (kg)
+	"g":  {"mass", 0.001, 0},
+	"kg": {"mass", 1, 0},
+	"t":  {"mass", 1000, 0},
+	"oz": {"mass", 0.028349523125, 0},
+	"lb": {"mass", 0.45359237, 0},
+
+	// speed (m/s)
+	"m/s":  {"speed", 1, 0},
+	"km/h": {"speed", 1 / 3.6, 0},
+	"mph":  {"speed", 0.44704, 0},
+	"kn":   {"speed", 1852 / 3600.0, 0},
+
+	// volume (m3)
+	"mL":  {"volume", 1e-6, 0},
+	"L":   {"volume", 0.001, 0},
+	"m3":  {"volume", 1, 0},
+	"gal": {"volume", 0.003785411784, 0},
+
+	// energy (J)
+	"J":   {"energy", 1, 0},
+	"kJ":  {"energy", 1000, 0},
+	"Wh":  {"energy", 3600, 0},
+	"kWh": {"energy", 3.6e6, 0},
+
+	// power (W)
+	"W":  {"power", 1, 0},
+	"kW": {"power", 1000, 0},
+	"hp": {"power", 745.69987158227022, 0},
+
+	// time (s)
+	"ms":  {"time", 0.001, 0},
+	"s":   {"time", 1, 0},
+	"min": {"time", 60, 0},
+	"h":   {"time", 3600, 0},
+}
+
+// RegisterUnit adds a unit to the unit table, or replaces an existing one.
+// A value in the unit is converted to the base unit of its dimension by
+// multiplying it by scale, then adding offset. Units are only converted
+// between units of the same dimension. Units used by deviceprofiles must
+// be registered before the Service is started.
+func RegisterUnit(name string, dimension string, scale float64, offset float64) error {
+	if name == "" || dimension == "" {
+		return fmt.Errorf("unit name and dimension must be set")
+	}
+
+	if scale == 0 {
+		return fmt.Errorf("unit: %s has zero scale", name)
+	}
+
+	unitsMutex.Lock()
+	units[name] = unit{dimension, scale, offset}
+	unitsMutex.Unlock()
+
+	return nil
+}
+
+// unitConversion converts values from a device unit to a published unit:
+// published = value * scale + offset.
+type unitConversion struct {
+	from, to      string
+	scale, offset float64
+}
+
+func newUnitConversion(from string, to string) (unitConversion, error) {
+	unitsMutex.RLock()
+	f, fromOK := units[from]
+	t, toOK := units[to]
+	unitsMutex.RUnlock()
+
+	if !fromOK {
+		return unitConversion{}, fmt.Errorf("unknown unit: %s", from)
+	}
+
+	if !toOK {
+		return unitConversion{}, fmt.Errorf("unknown unit: %s", to)
+	}
+
+	if f.dimension != t.dimension {
+		return unitConversion{}, fmt.Errorf("can't convert %s (%s) to %s (%s)", from, f.dimension, to, t.dimension)
+	}
+
+	return unitConversion{
+		from:   from,
+		to:     to,
+		scale:  f.scale / t.scale,
+		offset: (f.offset - t.offset) / t.scale,
+	}, nil
+}
+
+// convert converts a value from the device unit to the published unit.
+func (c unitConversion) convert(v float64) float64 {
+	return roundConversion(v*c.scale + c.offset)
+}
+
+// invert converts a value from the published unit to the device unit.
+func (c unitConversion) invert(v float64) float64 {
+	return roundConversion((v - c.offset) / c.scale)
+}
+
+// roundConversion rounds a converted value to 12 significant digits, which
+// removes the error accumulated by conversion via the base unit, e.g.
+// 212°F is 100°C rather than 100.00000000000004°C.
+func roundConversion(v float64) float64 {
+	r, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 12, 64), 64)
+	if err != nil {
+		return v
+	}
+
+	return r
+}
+
+// unitConversionOf returns the unit conversion of the given DeviceObject,
+// if its published unit differs from its device unit.
+func unitConversionOf(devObj *models.DeviceObject) (unitConversion, bool, error) {
+	to, ok := AttributeString(devObj.Attributes, PublishUnitsAttribute)
+	from := devObj.Properties.Units.DefaultValue
+	if !ok || to == "" || to == from {
+		return unitConversion{}, false, nil
+	}
+
+	if from == "" {
+		return unitConversion{}, false, fmt.Errorf("devobject: %s has %s: %s but no units", devObj.Name, PublishUnitsAttribute, to)
+	}
+
+	c, err := newUnitConversion(from, to)
+	if err != nil {
+		return c, false, fmt.Errorf("devobject: %s %v", devObj.Name, err)
+	}
+
+	return c, true, nil
+}
+
+// publishedProperties returns the PropertyValue and unit of readings of
+// the given DeviceObject as published, i.e. with the minimum, maximum and
+// default value converted to the published unit. Integer types are
+// published as Float64 if units are converted.
+func publishedProperties(devObj *models.DeviceObject) (models.PropertyValue, string) {
+	pv := devObj.Properties.Value
+
+	c, ok, _ := unitConversionOf(devObj)
+	if !ok {
+		return pv, devObj.Properties.Units.DefaultValue
+	}
+
+	for _, s := range []*string{&pv.Minimum, &pv.Maximum, &pv.DefaultValue} {
+		if f, err := strconv.ParseFloat(*s, 64); err == nil {
+			*s = strconv.FormatFloat(c.convert(f), 'f', -1, 64)
+		}
+	}
+
+	if isIntegerType(pv.Type) {
+		pv.Type = "Float64"
+	}
+
+	return pv, c.to
+}
+
+// isIntegerType returns whether a PropertyValue type is an integer type.
+func isIntegerType(pvType string) bool {
+	t, ok := propertyTypes[strings.ToLower(pvType)]
+	return ok && t >= Uint8 && t <= Int64
+}
+
+// convertUnits converts a numeric result, or each element of a numeric
+// array result, to the published unit of its DeviceObject. Integer
+// results are converted to Float64 (or Float64Array) results, so that
+// precision isn't lost.
+func (cr *CommandResult) convertUnits(devObj *models.DeviceObject) error {
+	c, ok, err := unitConversionOf(devObj)
+	if err != nil || !ok {
+		return err
+	}
+
+	elem, isArray := arrayElementType(cr.Type)
+	switch elem {
+	case Bool, String, Binary, JSON:
+		return fmt.Errorf("can't convert units of %v result", cr.Type)
+	}
+
+	to := elem
+	if elem != Float32 {
+		to = Float64
+	}
+
+	bits := uint(resultBits(elem))
+	out := make([]byte, 0, len(cr.NumericResult)/int(bits/8)*resultBits(to)/8)
+	for b := cr.NumericResult; len(b) >= int(bits/8); b = b[bits/8:] {
+		f := c.convert(elementFloat(elem, getUint(b[:bits/8]), bits))
+
+		u, err := floatElement(to, f, uint(resultBits(to)))
+		if err != nil {
+			return err
+		}
+
+		buf := make([]byte, resultBits(to)/8)
+		putUint(buf, u)
+		out = append(out, buf...)
+	}
+
+	cr.NumericResult = out
+	cr.Type = to
+	if isArray {
+		cr.Type = Uint8Array + (to - Uint8)
+	}
+
+	return nil
+}
+
+// unconvertParams converts the JSON encoded parameters of a PUT command,
+// which are keyed by ResourceOperation parameter name, from the published
+// unit of their DeviceObject to its device unit. If none of the given
+// operations' DeviceObjects convert units, params is returned as-is.
+// Parameters of integer types are rounded to the nearest integer. Each
+// converted value keeps its JSON type, string or number.
+func unconvertParams(devName string, ops []models.ResourceOperation, params string) (string, error) {
+	type paramConversion struct {
+		unitConversion
+		integer bool
+	}
+
+	convs := make(map[string]paramConversion)
+	for i := range ops {
+		op := &ops[i]
+		devObj := pc.getDeviceObjectByName(devName, op)
+		if devObj == nil {
+			continue
+		}
+
+		c, ok, err := unitConversionOf(devObj)
+		if err != nil {
+			return "", err
+		}
+
+		if ok && op.Parameter != "" {
+			convs[op.Parameter] = paramConversion{c, isIntegerType(devObj.Properties.Value.Type)}
+		}
+	}
+
+	if len(convs) == 0 {
+		return params, nil
+	}
+
+	var values map[string]interface{}
+	dec := json.NewDecoder(bytes.NewBufferString(params))
+	dec.UseNumber()
+
+	err := dec.Decode(&values)
+	if err != nil {
+		return "", fmt.Errorf("invalid parameters; expected JSON object: %v", err)
+	}
+
+	for name, c := range convs {
+		v, ok := values[name]
+		if !ok {
+			continue
+		}
+
+		var s string
+		switch v := v.(type) {
+		case string:
+			s = v
+		case json.Number:
+			s = v.String()
+		default:
+			return "", fmt.Errorf("value for parameter: %s must be a number", name)
+		}
+
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return "", fmt.Errorf("value for parameter: %s must be a number", name)
+		}
+
+		// integer parameters are rounded to the nearest device value
+		f = c.invert(f)
+		if c.integer {
+			f = math.Floor(f + 0.5)
+		}
+
+		// values are written back as they came in, as a string or a number
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+		if _, isString := v.(string); isString {
+			values[name] = s
+		} else {
+			values[name] = json.Number(s)
+		}
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,206 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2018 Canonical Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
+	"github.com/edgexfoundry/edgex-go/pkg/models"
+)
+
+// paramsDriver is a ProtocolDriver which records the params of the last
+// command it handles.
+type paramsDriver struct {
+	params string
+}
+
+func (p *paramsDriver) DisconnectDevice(address *models.Addressable) error {
+	return nil
+}
+
+func (p *paramsDriver) Initialize(s *Service, lc logger.LoggingClient, asyncCh <-chan *CommandResult) error {
+	return nil
+}
+
+func (p *paramsDriver) HandleCommands(ctx context.Context, d models.Device, reqs []CommandRequest, params string) ([]CommandResult, error) {
+	p.params = params
+	return []CommandResult{}, nil
+}
+
+func (p *paramsDriver) Stop(force bool) error {
+	return nil
+}
+
+func unitObject(typ string, from string, to string) *models.DeviceObject {
+	do := &models.DeviceObject{Name: "temperature", Attributes: map[string]interface{}{PublishUnitsAttribute: to}}
+	do.Properties.Value = models.PropertyValue{Type: typ, Minimum: "-40", Maximum: "212"}
+	do.Properties.Units.DefaultValue = from
+	return do
+}
+
+func TestUnitConversion(t *testing.T) {
+	var tests = []struct {
+		name     string
+		from     string
+		to       string
+		value    float64
+		expected float64
+	}{
+		{"F to C", "°F", "°C", 212, 100},
+		{"C to F", "C", "F", -40, -40},
+		{"C to K", "°C", "K", 0, 273.15},
+		{"psi to kPa", "psi", "kPa", 1, 6.894757293168361},
+		{"km/h to m/s", "km/h", "m/s", 36, 10},
+		{"kWh to J", "kWh", "J", 1, 3.6e6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newUnitConversion(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("newUnitConversion: failed: %v", err)
+			}
+
+			v := c.convert(tt.value)
+			if math.Abs(v-tt.expected) > 1e-9 {
+				t.Errorf("convert: expected: %g, got: %g", tt.expected, v)
+			}
+
+			if inv := c.invert(v); math.Abs(inv-tt.value) > 1e-9 {
+				t.Errorf("invert: expected: %g, got: %g", tt.value, inv)
+			}
+		})
+	}
+
+	if _, err := newUnitConversion("psi", "°C"); err == nil {
+		t.Errorf("newUnitConversion: expected error for different dimensions")
+	}
+
+	if _, err := newUnitConversion("furlong", "m"); err == nil {
+		t.Errorf("newUnitConversion: expected error for unknown unit")
+	}
+}
+
+func TestRegisterUnit(t *testing.T) {
+	if err := RegisterUnit("furlong", "length", 201.168, 0); err != nil {
+		t.Fatalf("RegisterUnit: failed: %v", err)
+	}
+	defer delete(units, "furlong")
+
+	c, err := newUnitConversion("furlong", "m")
+	if err != nil || c.convert(1) != 201.168 {
+		t.Errorf("RegisterUnit: registered unit not converted: %v", err)
+	}
+
+	if err := RegisterUnit("zero", "length", 0, 0); err == nil {
+		t.Errorf("RegisterUnit: expected error for zero scale")
+	}
+}
+
+func TestConvertUnits(t *testing.T) {
+	cr := NewInt16Result(nil, nil, 0, 212)
+	err := cr.convertUnits(unitObject("Int16", "°F", "°C"))
+	if v, _ := cr.Float64Value(); err != nil || cr.Type != Float64 || v != 100 {
+		t.Errorf("convertUnits: expected Float64 100, got: %v %s, %v", cr.Type, cr.toString(), err)
+	}
+
+	cr = NewFloat32ArrayResult(nil, nil, 0, []float32{1000, 2500})
+	err = cr.convertUnits(unitObject("Float32Array", "m", "km"))
+	if err != nil || cr.Type != Float32Array || cr.toString() != "[1,2.5]" {
+		t.Errorf("convertUnits: expected Float32Array [1,2.5], got: %v %s, %v", cr.Type, cr.toString(), err)
+	}
+
+	cr = NewStringResult(nil, nil, 0, "hot")
+	if err = cr.convertUnits(unitObject("String", "°F", "°C")); err == nil {
+		t.Errorf("convertUnits: expected error for String result")
+	}
+
+	cr = NewInt16Result(nil, nil, 0, 212)
+	if err = cr.convertUnits(unitObject("Int16", "°F", "")); err != nil || cr.Type != Int16 {
+		t.Errorf("convertUnits: unconverted result changed: %v, %v", cr.Type, err)
+	}
+}
+
+func TestPublishedProperties(t *testing.T) {
+	pv, units := publishedProperties(unitObject("Int16", "°F", "°C"))
+	if units != "°C" || pv.Type != "Float64" || pv.Minimum != "-40" || pv.Maximum != "100" {
+		t.Errorf("publishedProperties: wrong properties: %v %s", pv, units)
+	}
+
+	do := unitObject("Int16", "°F", "°C")
+	do.Attributes = nil
+	pv, units = publishedProperties(do)
+	if units != "°F" || pv.Type != "Int16" || pv.Maximum != "212" {
+		t.Errorf("publishedProperties: wrong unconverted properties: %v %s", pv, units)
+	}
+
+	if _, _, err := unitConversionOf(unitObject("Int16", "", "°C")); err == nil {
+		t.Errorf("unitConversionOf: expected error for missing device units")
+	}
+}
+
+func TestUnconvertParams(t *testing.T) {
+	pc = &profileCache{objects: map[string]map[string]models.DeviceObject{
+		"dev": {
+			"setpoint": *unitObject("Int16", "°F", "°C"),
+			"mode":     {Name: "mode"},
+		},
+	}}
+
+	ops := []models.ResourceOperation{
+		{Object: "setpoint", Parameter: "setpoint"},
+		{Object: "mode", Parameter: "mode"},
+	}
+
+	params, err := unconvertParams("dev", ops, `{"setpoint":"21.1","mode":"auto"}`)
+	if err != nil || params != `{"mode":"auto","setpoint":"70"}` {
+		t.Errorf("unconvertParams: expected converted setpoint string, got: %s, %v", params, err)
+	}
+
+	params, err = unconvertParams("dev", ops, `{"setpoint":21.1}`)
+	if err != nil || params != `{"setpoint":70}` {
+		t.Errorf("unconvertParams: expected converted setpoint number, got: %s, %v", params, err)
+	}
+
+	if _, err = unconvertParams("dev", ops, `{"setpoint":"warm"}`); err == nil {
+		t.Errorf("unconvertParams: expected error for non-numeric value")
+	}
+
+	params, err = unconvertParams("dev", ops[1:], `{"mode":"auto"}`)
+	if err != nil || params != `{"mode":"auto"}` {
+		t.Errorf("unconvertParams: expected unchanged params, got: %s, %v", params, err)
+	}
+}
+
+func TestExecuteCommandMappedUnits(t *testing.T) {
+	driver := &paramsDriver{}
+	svc = &Service{c: &Config{Device: DeviceInfo{MaxCmdOps: 128}}, lc: logger.NewClient("units_test", false, ""), proto: driver}
+
+	// mappings apply to published values, so are undone before units are
+	pc = &profileCache{
+		objects: map[string]map[string]models.DeviceObject{"dev": {"setpoint": *unitObject("Int16", "°F", "°C")}},
+		commands: map[string]map[string]map[string][]models.ResourceOperation{
+			"dev": {"setpoint": {"set": {{Object: "setpoint", Parameter: "setpoint",
+				Mappings: map[string]string{"20": "COMFORT", "16": "ECO"}}}}},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	executeCommand(context.Background(), rr, &models.Device{Name: "dev"}, "setpoint", http.MethodPut, `{"setpoint":"COMFORT"}`, commandOptions{})
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("executeCommand: expected status: %d, got: %d %s", http.StatusNoContent, rr.Code, rr.Body.String())
+	}
+
+	if driver.params != `{"setpoint":"68"}` {
+		t.Errorf("executeCommand: expected unmapped then unconverted params, got: %s", driver.params)
+	}
+}
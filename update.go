@@ -17,37 +17,59 @@ import (
 
 func callbackHandler(w http.ResponseWriter, req *http.Request) {
 	defer req.Body.Close()
-	dec := json.NewDecoder(req.Body)
-	cbAlert := models.CallbackAlert{}
+	body, err := readBody(req)
+	if err == errRequestTooLarge {
+		msg := fmt.Sprintf("request body exceeds %d bytes; %s %s", maxRequestSize(), req.Method, req.URL)
+		svc.lc.Error(msg)
+		writeError(w, http.StatusRequestEntityTooLarge, msg, "", "") // status=413
+		return
+	}
 
-	err := dec.Decode(&cbAlert)
+	cbAlert := models.CallbackAlert{}
+	if err == nil {
+		err = json.Unmarshal(body, &cbAlert)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, err.Error(), "", "") // status=400
 		svc.lc.Error(fmt.Sprintf("Invalid callback request: %v", err))
 		return
 	}
 
 	if (cbAlert.Id == "") || (cbAlert.ActionType == "") {
-		http.Error(w, "Missing parameters", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Missing parameters", "", "") // status=400
 		svc.lc.Error(fmt.Sprintf("Missing callback parameters"))
 		return
 	}
 
 	// It was decided at the last F2F, that the one Core Metadata callback
 	// function to be supported for Dehli is handling changes to a device's
-	// adminState (LOCKED or UNLOCKED).
+	// adminState (LOCKED or UNLOCKED). Device deletions are also handled,
+	// so that removed devices are dropped from the cache.
 	if (cbAlert.ActionType == models.DEVICE) && (req.Method == http.MethodPut) {
 		err = dc.UpdateAdminState(cbAlert.Id)
 		if err == nil {
 			svc.lc.Info(fmt.Sprintf("Updated device %s admin state", cbAlert.Id))
 		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, err.Error(), cbAlert.Id, "") // status=500
 			svc.lc.Error(fmt.Sprintf("Couldn't update device %s admin state: %v", cbAlert.Id, err.Error()))
 			return
 		}
+	} else if (cbAlert.ActionType == models.DEVICE) && (req.Method == http.MethodDelete) {
+		err = dc.RemoveById(cbAlert.Id)
+		if err == nil {
+			svc.lc.Info(fmt.Sprintf("Removed device %s", cbAlert.Id))
+		} else if err == errDeviceBusy || err == errDeviceRemoving {
+			writeError(w, http.StatusConflict, err.Error(), cbAlert.Id, "") // status=409
+			svc.lc.Error(fmt.Sprintf("Couldn't remove device %s: %v", cbAlert.Id, err.Error()))
+			return
+		} else {
+			writeError(w, http.StatusInternalServerError, err.Error(), cbAlert.Id, "") // status=500
+			svc.lc.Error(fmt.Sprintf("Couldn't remove device %s: %v", cbAlert.Id, err.Error()))
+			return
+		}
 	} else {
 		svc.lc.Error(fmt.Sprintf("Invalid device method and/or action type: %s - %s", req.Method, cbAlert.ActionType))
-		http.Error(w, "Invalid device method and/or action type", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Invalid device method and/or action type", "", "") // status=400
 		return
 	}
 
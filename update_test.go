@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	logger "github.com/edgexfoundry/edgex-go/pkg/clients/logging"
@@ -29,11 +30,13 @@ func TestUpdate(t *testing.T) {
 		{"Invalid type", http.MethodPut, `{"id":"5b9a4f9a64562a2f966fdb0b","type":"INVALID"}`, http.StatusBadRequest},
 		{"Invalid method", http.MethodPost, `{"id":"5b9a4f9a64562a2f966fdb0b","type":"DEVICE"}`, http.StatusBadRequest},
 		{"Invalid id", http.MethodPut, `{"id":"5b9a4f9a64562a2f966fdb0b","type":"DEVICE"}`, http.StatusInternalServerError},
+		{"Delete invalid id", http.MethodDelete, `{"id":"5b9a4f9a64562a2f966fdb0b","type":"DEVICE"}`, http.StatusInternalServerError},
+		{"Oversized body", http.MethodPut, `{"id":"` + strings.Repeat("0", defaultMaxRequestSize) + `","type":"DEVICE"}`, http.StatusRequestEntityTooLarge},
 	}
 
 	lc := logger.NewClient("update_test", false, "")
 	r := mux.NewRouter().PathPrefix(apiV1).Subrouter()
-	svc = &Service{Name: "update-test", lc: lc, r: r, locked: true}
+	svc = &Service{Name: "update-test", c: &Config{}, lc: lc, r: r, locked: true}
 	initUpdate()
 
 	for _, tt := range tests {
@@ -46,7 +49,7 @@ func TestUpdate(t *testing.T) {
 			svc.r.ServeHTTP(rr, req)
 			if status := rr.Code; status != tt.code {
 				t.Errorf("CallbackHandler: handler returned wrong status code: got %v want %v",
-					status, http.StatusLocked)
+					status, tt.code)
 			}
 		})
 	}